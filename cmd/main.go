@@ -13,10 +13,20 @@ import (
 	"time"
 
 	"github.com/citadel-corp/cats-social/internal/cat"
+	catdiscovery "github.com/citadel-corp/cats-social/internal/cat_discovery"
 	catmatch "github.com/citadel-corp/cats-social/internal/cat_match"
+	catrecommendation "github.com/citadel-corp/cats-social/internal/cat_recommendation"
+	cattransfer "github.com/citadel-corp/cats-social/internal/cat_transfer"
 	"github.com/citadel-corp/cats-social/internal/common/db"
 	"github.com/citadel-corp/cats-social/internal/common/middleware"
+	"github.com/citadel-corp/cats-social/internal/email"
+	"github.com/citadel-corp/cats-social/internal/event"
+	"github.com/citadel-corp/cats-social/internal/export"
+	matchmessage "github.com/citadel-corp/cats-social/internal/match_message"
+	"github.com/citadel-corp/cats-social/internal/notification"
+	"github.com/citadel-corp/cats-social/internal/outbox"
 	"github.com/citadel-corp/cats-social/internal/user"
+	"github.com/citadel-corp/cats-social/internal/webhook"
 	"github.com/gorilla/mux"
 	"github.com/lmittmann/tint"
 )
@@ -54,19 +64,102 @@ func main() {
 
 	// initialize user domain
 	userRepository := user.NewRepository(db)
-	userService := user.NewService(userRepository)
-	userHandler := user.NewHandler(userService)
+
+	// initialize event domain, match and message changes are pushed to connected clients
+	eventHub := event.NewHub(event.DefaultBacklog)
+	eventHandler := event.NewHandler(eventHub)
+
+	// initialize webhook domain, deliveries are queued on publish and sent by the dispatcher
+	webhookRepository := webhook.NewRepository(db)
+	webhookService := webhook.NewService(webhookRepository)
+	webhookHandler := webhook.NewHandler(webhookService)
 
 	// initialize cat domain
 	catRepository := cat.NewRepository(db)
 	catService := cat.NewService(catRepository)
 	catHandler := cat.NewHandler(catService)
 
-	// initialize cat match domain
+	// initialize email domain, without an SMTP server emails are written to a local outbox
 	catMatchRepository := catmatch.NewRepository(db)
-	catMatchService := catmatch.NewService(catMatchRepository, catRepository)
+	mailer, err := newMailer()
+	if err != nil {
+		slog.Error(fmt.Sprintf("Cannot set up email: %v", err))
+		os.Exit(1)
+	}
+	emailSender := email.NewSender(mailer, os.Getenv("EMAIL_FROM"))
+	emailConfig := email.Config{
+		BaseURL: os.Getenv("BASE_URL"),
+		Secret:  os.Getenv("EMAIL_UNSUBSCRIBE_SECRET"),
+	}
+	if emailConfig.Secret == "" {
+		emailConfig.Secret = os.Getenv("JWT_SECRET")
+	}
+	if emailConfig.Secret == "" {
+		// anyone could sign unsubscribe links with an empty key
+		slog.Error("EMAIL_UNSUBSCRIBE_SECRET or JWT_SECRET must be set")
+		os.Exit(1)
+	}
+	emailService := email.NewService(emailSender, emailConfig, userRepository, catRepository, catMatchRepository)
+	emailHandler := email.NewHandler(emailService)
+
+	userService := user.NewService(userRepository, emailService)
+	userHandler := user.NewHandler(userService)
+
+	// initialize notification domain, every published event is also kept in the user's notifications
+	notificationRepository := notification.NewRepository(db)
+	notificationService := notification.NewService(notificationRepository)
+	notificationHandler := notification.NewHandler(notificationService)
+	// messages and reminders are published right away, they aren't domain events
+	eventPublisher := event.Fanout{notificationService, eventHub}
+
+	// initialize outbox, the domain events recorded with every cat and match change are handed to its
+	// subscribers, which tell the users involved through every channel
+	outboxDispatcher := outbox.NewDispatcher(outbox.NewRepository(db), durationEnv("OUTBOX_POLL_INTERVAL"))
+	catEvents := []outbox.Type{outbox.CatCreated, outbox.CatUpdated, outbox.CatDeleted, outbox.CatTransferred}
+	matchEvents := []outbox.Type{outbox.MatchRequested, outbox.MatchApproved, outbox.MatchRejected, outbox.MatchWithdrawn,
+		outbox.MatchExpired, outbox.MatchCancelled, outbox.MatchDissolved}
+	outboxDispatcher.Subscribe("webhooks.cat", webhook.NewOutboxRelay(webhookService, cat.UserEvents), catEvents...)
+	outboxDispatcher.Subscribe("webhooks.match", webhook.NewOutboxRelay(webhookService, catmatch.UserEvents), matchEvents...)
+	outboxDispatcher.Subscribe("events.cat", event.NewOutboxRelay(eventHub, cat.UserEvents), catEvents...)
+	outboxDispatcher.Subscribe("events.match", event.NewOutboxRelay(eventHub, catmatch.UserEvents), matchEvents...)
+	outboxDispatcher.Subscribe("notifications.match", notification.NewOutboxRelay(notificationService, catmatch.UserEvents), matchEvents...)
+	outboxDispatcher.Subscribe("emails.match", email.NewOutboxRelay(emailService, catmatch.UserEvents), matchEvents...)
+
+	// initialize cat match domain
+	catMatchConfig := catmatch.Config{
+		AutoApproveReciprocal: os.Getenv("MATCH_AUTO_APPROVE_RECIPROCAL") == "true",
+		TTL:                   durationEnv("MATCH_TTL"),
+		ReminderBefore:        durationEnv("MATCH_REMINDER_BEFORE"),
+		ExpiryInterval:        durationEnv("MATCH_EXPIRY_INTERVAL"),
+		DissolveCooldown:      durationEnv("MATCH_DISSOLVE_COOLDOWN"),
+	}
+	catMatchService := catmatch.NewService(catMatchRepository, catRepository, catMatchConfig)
 	catMatchHandler := catmatch.NewHandler(catMatchService)
 
+	// initialize match message domain
+	matchMessageRepository := matchmessage.NewRepository(db)
+	matchMessageService := matchmessage.NewService(matchMessageRepository, catMatchRepository, event.Fanout{eventPublisher, webhookService})
+	matchMessageHandler := matchmessage.NewHandler(matchMessageService)
+
+	// initialize cat recommendation domain
+	catRecommendationRepository := catrecommendation.NewRepository(db)
+	catRecommendationService := catrecommendation.NewService(catRecommendationRepository, catRepository, nil)
+	catRecommendationHandler := catrecommendation.NewHandler(catRecommendationService)
+
+	// initialize cat discovery domain
+	catDiscoveryRepository := catdiscovery.NewRepository(db)
+	catDiscoveryService := catdiscovery.NewService(catDiscoveryRepository, catRepository, catRecommendationService, catMatchService)
+	catDiscoveryHandler := catdiscovery.NewHandler(catDiscoveryService)
+
+	// initialize cat transfer domain
+	catTransferRepository := cattransfer.NewRepository(db)
+	catTransferService := cattransfer.NewService(catTransferRepository, catRepository, userRepository)
+	catTransferHandler := cattransfer.NewHandler(catTransferService)
+
+	// initialize export domain
+	exportService := export.NewService(userRepository, catRepository, catMatchRepository)
+	exportHandler := export.NewHandler(exportService)
+
 	r := mux.NewRouter()
 	r.Use(middleware.Logging)
 	r.Use(middleware.PanicRecoverer)
@@ -81,6 +174,37 @@ func main() {
 	ur := v1.PathPrefix("/user").Subrouter()
 	ur.HandleFunc("/register", userHandler.CreateUser).Methods(http.MethodPost)
 	ur.HandleFunc("/login", userHandler.Login).Methods(http.MethodPost)
+	ur.HandleFunc("/me/location", middleware.Authorized(userHandler.UpdateLocation)).Methods(http.MethodPut)
+	ur.HandleFunc("/me/favorites", middleware.Authorized(catHandler.GetFavoriteCatList)).Methods(http.MethodGet)
+	ur.HandleFunc("/me/export", middleware.Authorized(exportHandler.Export)).Methods(http.MethodGet)
+	ur.HandleFunc("/me/export/{id}", middleware.Authorized(exportHandler.GetJob)).Methods(http.MethodGet)
+	ur.HandleFunc("/me/export/{id}/download", middleware.Authorized(exportHandler.Download)).Methods(http.MethodGet)
+
+	// email routes
+	er := v1.PathPrefix("/email").Subrouter()
+	er.HandleFunc("/unsubscribe", emailHandler.UnsubscribePage).Methods(http.MethodGet)
+	er.HandleFunc("/unsubscribe", emailHandler.Unsubscribe).Methods(http.MethodPost)
+	er.HandleFunc("/subscribe", middleware.Authorized(emailHandler.Resubscribe)).Methods(http.MethodPost)
+
+	// event routes
+	v1.HandleFunc("/events", middleware.AuthorizedStream(eventHandler.Stream)).Methods(http.MethodGet)
+
+	// notification routes
+	nr := v1.PathPrefix("/notifications").Subrouter()
+	nr.HandleFunc("", middleware.Authorized(notificationHandler.GetNotificationList)).Methods(http.MethodGet)
+	nr.HandleFunc("/read", middleware.Authorized(notificationHandler.MarkAllRead)).Methods(http.MethodPost)
+	nr.HandleFunc("/preferences", middleware.Authorized(notificationHandler.GetPreferences)).Methods(http.MethodGet)
+	nr.HandleFunc("/preferences", middleware.Authorized(notificationHandler.UpdatePreferences)).Methods(http.MethodPut)
+	nr.HandleFunc("/{id}/read", middleware.Authorized(notificationHandler.MarkRead)).Methods(http.MethodPost)
+
+	// webhook routes
+	wr := v1.PathPrefix("/webhooks").Subrouter()
+	wr.HandleFunc("", middleware.Authorized(webhookHandler.GetWebhookList)).Methods(http.MethodGet)
+	wr.HandleFunc("", middleware.Authorized(webhookHandler.CreateWebhook)).Methods(http.MethodPost)
+	wr.HandleFunc("/{id}", middleware.Authorized(webhookHandler.UpdateWebhook)).Methods(http.MethodPut)
+	wr.HandleFunc("/{id}", middleware.Authorized(webhookHandler.DeleteWebhook)).Methods(http.MethodDelete)
+	wr.HandleFunc("/{id}/deliveries", middleware.Authorized(webhookHandler.GetDeliveryList)).Methods(http.MethodGet)
+	wr.HandleFunc("/{id}/deliveries/{deliveryId}/redeliver", middleware.Authorized(webhookHandler.Redeliver)).Methods(http.MethodPost)
 
 	// cat match routes
 	cmr := v1.PathPrefix("/cat/match").Subrouter()
@@ -88,20 +212,59 @@ func main() {
 	cmr.HandleFunc("", middleware.Authorized(catMatchHandler.Create)).Methods(http.MethodPost)
 	cmr.HandleFunc("/approve", middleware.Authorized(catMatchHandler.Approve)).Methods(http.MethodPost)
 	cmr.HandleFunc("/reject", middleware.Authorized(catMatchHandler.Reject)).Methods(http.MethodPost)
-	cmr.HandleFunc("/{id}", middleware.Authorized(catMatchHandler.Delete)).Methods(http.MethodDelete)
+	cmr.HandleFunc("/{id}", middleware.Authorized(catMatchHandler.Withdraw)).Methods(http.MethodDelete)
+	cmr.HandleFunc("/{id}/dissolve", middleware.Authorized(catMatchHandler.Dissolve)).Methods(http.MethodPost)
+	cmr.HandleFunc("/{id}/view", middleware.Authorized(catMatchHandler.MarkViewed)).Methods(http.MethodPost)
+	cmr.HandleFunc("/{id}/messages", middleware.Authorized(matchMessageHandler.GetMessageList)).Methods(http.MethodGet)
+	cmr.HandleFunc("/{id}/messages", middleware.Authorized(matchMessageHandler.Send)).Methods(http.MethodPost)
+	cmr.HandleFunc("/{id}/messages/read", middleware.Authorized(matchMessageHandler.MarkRead)).Methods(http.MethodPost)
+
+	// cat transfer routes
+	ctr := v1.PathPrefix("/cat/transfer").Subrouter()
+	ctr.HandleFunc("", middleware.Authorized(catTransferHandler.GetTransferList)).Methods(http.MethodGet)
+	ctr.HandleFunc("/{id}/accept", middleware.Authorized(catTransferHandler.Accept)).Methods(http.MethodPost)
+	ctr.HandleFunc("/{id}/decline", middleware.Authorized(catTransferHandler.Decline)).Methods(http.MethodPost)
+	ctr.HandleFunc("/{id}", middleware.Authorized(catTransferHandler.Cancel)).Methods(http.MethodDelete)
 
 	// cat management routes
 	cr := v1.PathPrefix("/cat").Subrouter()
 	cr.HandleFunc("", middleware.Authorized(catHandler.GetCatList)).Methods(http.MethodGet)
 	cr.HandleFunc("", middleware.Authorized(catHandler.CreateCat)).Methods(http.MethodPost)
+	cr.HandleFunc("/import", middleware.Authorized(catHandler.ImportCats)).Methods(http.MethodPost)
+	cr.HandleFunc("/import/{id}", middleware.Authorized(catHandler.GetImportJob)).Methods(http.MethodGet)
+	cr.HandleFunc("/tags", middleware.Authorized(catHandler.GetTagList)).Methods(http.MethodGet)
 	cr.HandleFunc("/{id}", middleware.Authorized(catHandler.UpdateCat)).Methods(http.MethodPut)
 	cr.HandleFunc("/{id}", middleware.Authorized(catHandler.DeleteCat)).Methods(http.MethodDelete)
+	cr.HandleFunc("/{id}/favorite", middleware.Authorized(catHandler.FavoriteCat)).Methods(http.MethodPost)
+	cr.HandleFunc("/{id}/favorite", middleware.Authorized(catHandler.UnfavoriteCat)).Methods(http.MethodDelete)
+	cr.HandleFunc("/{id}/history", middleware.Authorized(catHandler.GetCatHistory)).Methods(http.MethodGet)
+	cr.HandleFunc("/{id}/images", middleware.Authorized(catHandler.GetCatImages)).Methods(http.MethodGet)
+	cr.HandleFunc("/{id}/images", middleware.Authorized(catHandler.AddCatImage)).Methods(http.MethodPost)
+	cr.HandleFunc("/{id}/images/order", middleware.Authorized(catHandler.ReorderCatImages)).Methods(http.MethodPut)
+	cr.HandleFunc("/{id}/images/{imageId}/cover", middleware.Authorized(catHandler.SetCatCoverImage)).Methods(http.MethodPut)
+	cr.HandleFunc("/{id}/images/{imageId}", middleware.Authorized(catHandler.UpdateCatImage)).Methods(http.MethodPatch)
+	cr.HandleFunc("/{id}/images/{imageId}", middleware.Authorized(catHandler.DeleteCatImage)).Methods(http.MethodDelete)
+	cr.HandleFunc("/{id}/recommendations", middleware.Authorized(catRecommendationHandler.GetRecommendationList)).Methods(http.MethodGet)
+	cr.HandleFunc("/{id}/discover", middleware.Authorized(catDiscoveryHandler.Discover)).Methods(http.MethodGet)
+	cr.HandleFunc("/{id}/discover/{candidateId}", middleware.Authorized(catDiscoveryHandler.Decide)).Methods(http.MethodPost)
+	cr.HandleFunc("/{id}/transfer", middleware.Authorized(catTransferHandler.Create)).Methods(http.MethodPost)
+	cr.HandleFunc("/{id}/ownership", middleware.Authorized(catTransferHandler.GetOwnershipHistory)).Methods(http.MethodGet)
 
 	httpServer := &http.Server{
 		Addr:     ":8080",
 		Handler:  r,
 		ErrorLog: slog.NewLogLogger(slogHandler, slog.LevelError),
 	}
+	// event streams stay open until closed, shutdown would otherwise wait for them until it times out
+	httpServer.RegisterOnShutdown(eventHandler.Close)
+
+	// background jobs stop when the server shuts down
+	bgCtx, bgCancel := context.WithCancel(context.Background())
+	defer bgCancel()
+	go emailSender.Run(bgCtx)
+	go outboxDispatcher.Run(bgCtx)
+	go webhook.NewDispatcher(webhookRepository, nil, durationEnv("WEBHOOK_POLL_INTERVAL")).Run(bgCtx)
+	go catmatch.NewExpirer(catMatchRepository, catMatchConfig, catmatch.NewPublishReminder(event.Fanout{eventPublisher, webhookService})).Run(bgCtx)
 
 	go func() {
 		slog.Info(fmt.Sprintf("HTTP server listening on %s", httpServer.Addr))
@@ -117,12 +280,44 @@ func main() {
 
 	// Block until termination signal received
 	<-stop
+	bgCancel()
 	shutdownCtx, shutdownRelease := context.WithTimeout(context.Background(), 10*time.Second)
 	defer shutdownRelease()
 
 	slog.Info(fmt.Sprintf("Shutting down HTTP server listening on %s", httpServer.Addr))
 	if err := httpServer.Shutdown(shutdownCtx); err != nil {
-		slog.Error("HTTP server shutdown error: %v", err)
+		slog.Error(fmt.Sprintf("HTTP server shutdown error: %v", err))
 	}
 	slog.Info("Shutdown complete.")
 }
+
+// durationEnv reads a duration like "72h" from the environment, zero if unset or invalid.
+func durationEnv(name string) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Invalid duration for %s: %v", name, err))
+		return 0
+	}
+	return d
+}
+
+// newMailer sends through SMTP_HOST when it is set, otherwise into the EMAIL_OUTBOX_DIR directory.
+func newMailer() (email.Mailer, error) {
+	host := os.Getenv("SMTP_HOST")
+	if host != "" {
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		return email.NewSMTPMailer(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD")), nil
+	}
+	dir := os.Getenv("EMAIL_OUTBOX_DIR")
+	if dir == "" {
+		dir = "outbox"
+	}
+	return email.NewFileMailer(dir)
+}
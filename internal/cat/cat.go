@@ -1,9 +1,14 @@
 package cat
 
-import "time"
+import (
+	"time"
+
+	"github.com/citadel-corp/cats-social/internal/common/geo"
+)
 
 type CatRace string
 type CatSex string
+type CatVisibility string
 
 const (
 	Persian          CatRace = "Persian"
@@ -19,12 +24,19 @@ const (
 
 	Male   CatSex = "male"
 	Female CatSex = "female"
+
+	// Public cats show up in search, unlisted ones only by direct id and private ones only to their owner
+	Public   CatVisibility = "public"
+	Unlisted CatVisibility = "unlisted"
+	Private  CatVisibility = "private"
 )
 
 var (
 	CatRacesInterface []interface{} = []interface{}{Persian, MaineCoon, Siamese, Ragdoll, Bengal, Sphynx, BritishShorthair, Abyssinian, ScottishFold, Birman}
 	CatSexesInterface []interface{} = []interface{}{Male, Female}
 
+	CatVisibilitiesInterface []interface{} = []interface{}{Public, Unlisted, Private}
+
 	CatRaces []CatRace = []CatRace{Persian, MaineCoon, Siamese, Ragdoll, Bengal, Sphynx, BritishShorthair, Abyssinian, ScottishFold, Birman}
 	CatSexes []CatSex  = []CatSex{Male, Female}
 )
@@ -40,5 +52,26 @@ type Cat struct {
 	Description string
 	HasMatched  bool
 	ImageURLS   []string
+	Tags        []string
+	Location    *geo.Location
+	Visibility  CatVisibility
+	Version     int
 	CreatedAt   time.Time
+
+	FavoriteCount int
+	IsFavorited   bool
+	DistanceKm    *float64
+	Images        []CatImage
+}
+
+// CatImage is a single photo of a cat's gallery. ImageURLS on Cat lists the same urls in gallery order.
+type CatImage struct {
+	ID        int64
+	UID       string
+	CatID     int64
+	URL       string
+	Position  int
+	Caption   string
+	IsPrimary bool
+	CreatedAt time.Time
 }
@@ -4,6 +4,14 @@ import "errors"
 
 var (
 	ErrCatNotFound      = errors.New("cat not found")
-	ErrCatHasMatched    = errors.New("cat has matched befor")
+	ErrCatHasMatched    = errors.New("cat is in an active match")
 	ErrValidationFailed = errors.New("validation failed")
+
+	ErrImageNotFound     = errors.New("cat image not found")
+	ErrLastImage         = errors.New("cat must keep at least one image")
+	ErrInvalidImageOrder = errors.New("image order must list every image of the cat exactly once")
+
+	ErrImportFormatUnsupported = errors.New("import format must be csv or ndjson")
+	ErrImportRowsInvalid       = errors.New("import has invalid rows")
+	ErrImportJobNotFound       = errors.New("import job not found")
 )
@@ -0,0 +1,42 @@
+package cat
+
+const (
+	FacetRace       = "race"
+	FacetSex        = "sex"
+	FacetAge        = "age"
+	FacetHasMatched = "hasMatched"
+
+	// age buckets, by age in months
+	AgeKitten = "kitten"
+	AgeYoung  = "young"
+	AgeAdult  = "adult"
+	AgeSenior = "senior"
+
+	youngMinAge  = 12
+	adultMinAge  = 36
+	seniorMinAge = 120
+)
+
+var Facets = []string{FacetRace, FacetSex, FacetAge, FacetHasMatched}
+
+// facetValues lists every value of a facet in display order, so values without cats are reported too.
+var facetValues = map[string][]string{
+	FacetRace:       catRaceValues(),
+	FacetSex:        {string(Male), string(Female)},
+	FacetAge:        {AgeKitten, AgeYoung, AgeAdult, AgeSenior},
+	FacetHasMatched: {"true", "false"},
+}
+
+type FacetCount struct {
+	Facet string
+	Value string
+	Count int
+}
+
+func catRaceValues() []string {
+	res := make([]string, len(CatRaces))
+	for i, race := range CatRaces {
+		res[i] = string(race)
+	}
+	return res
+}
@@ -13,6 +13,9 @@ import (
 	"github.com/gorilla/schema"
 )
 
+// import files are read fully before processing, so cap their size
+const maxImportSize = 10 << 20
+
 type Handler struct {
 	service Service
 }
@@ -37,7 +40,7 @@ func (h *Handler) GetCatList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cats, err := h.service.List(r.Context(), req, userID)
+	cats, meta, err := h.service.List(r.Context(), req, userID)
 	if err != nil {
 		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
 			Message: "Internal server error",
@@ -48,6 +51,7 @@ func (h *Handler) GetCatList(w http.ResponseWriter, r *http.Request) {
 	response.JSON(w, http.StatusOK, response.ResponseBody{
 		Message: "success",
 		Data:    cats,
+		Meta:    meta,
 	})
 }
 
@@ -170,6 +174,475 @@ func (h *Handler) DeleteCat(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (h *Handler) GetCatHistory(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{})
+		return
+	}
+	params := mux.Vars(r)
+	id := params["id"]
+	history, err := h.service.History(r.Context(), id, userID)
+	if errors.Is(err, ErrCatNotFound) {
+		response.JSON(w, http.StatusNotFound, response.ResponseBody{
+			Message: "Not found",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
+			Message: "Internal server error",
+			Error:   err.Error(),
+		})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.ResponseBody{
+		Message: "success",
+		Data:    history,
+	})
+}
+
+func (h *Handler) GetFavoriteCatList(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{})
+		return
+	}
+
+	newSchema := schema.NewDecoder()
+	newSchema.IgnoreUnknownKeys(true)
+
+	var req ListCatPayload
+	if err = newSchema.Decode(&req, r.URL.Query()); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.ResponseBody{})
+		return
+	}
+
+	cats, meta, err := h.service.ListFavorites(r.Context(), req, userID)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
+			Message: "Internal server error",
+			Error:   err.Error(),
+		})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.ResponseBody{
+		Message: "success",
+		Data:    cats,
+		Meta:    meta,
+	})
+}
+
+func (h *Handler) FavoriteCat(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{})
+		return
+	}
+	params := mux.Vars(r)
+	id := params["id"]
+	err = h.service.Favorite(r.Context(), id, userID)
+	if errors.Is(err, ErrCatNotFound) {
+		response.JSON(w, http.StatusNotFound, response.ResponseBody{
+			Message: "Not found",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
+			Message: "Internal server error",
+			Error:   err.Error(),
+		})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.ResponseBody{
+		Message: "success",
+	})
+}
+
+func (h *Handler) UnfavoriteCat(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{})
+		return
+	}
+	params := mux.Vars(r)
+	id := params["id"]
+	err = h.service.Unfavorite(r.Context(), id, userID)
+	if errors.Is(err, ErrCatNotFound) {
+		response.JSON(w, http.StatusNotFound, response.ResponseBody{
+			Message: "Not found",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
+			Message: "Internal server error",
+			Error:   err.Error(),
+		})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.ResponseBody{
+		Message: "success",
+	})
+}
+
+func (h *Handler) ImportCats(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{})
+		return
+	}
+
+	newSchema := schema.NewDecoder()
+	newSchema.IgnoreUnknownKeys(true)
+
+	var req ImportCatPayload
+	if err = newSchema.Decode(&req, r.URL.Query()); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
+			Message: "Bad request",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxImportSize)
+	req.Rows, err = ParseImportRows(body, ImportFormat(req.Format, r.Header.Get("Content-Type")))
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
+			Message: "Failed to read import file",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	report, job, err := h.service.Import(r.Context(), req, userID)
+	if errors.Is(err, ErrValidationFailed) {
+		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
+			Message: "Bad request",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, ErrImportRowsInvalid) {
+		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
+			Message: "Bad request",
+			Data:    report,
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
+			Message: "Internal server error",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if job != nil {
+		response.JSON(w, http.StatusAccepted, response.ResponseBody{
+			Message: "accepted",
+			Data:    job,
+		})
+		return
+	}
+	status := http.StatusCreated
+	if report.DryRun {
+		status = http.StatusOK
+	}
+	response.JSON(w, status, response.ResponseBody{
+		Message: "success",
+		Data:    report,
+	})
+}
+
+func (h *Handler) GetImportJob(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{})
+		return
+	}
+	params := mux.Vars(r)
+	id := params["id"]
+	job, err := h.service.GetImportJob(r.Context(), id, userID)
+	if errors.Is(err, ErrImportJobNotFound) {
+		response.JSON(w, http.StatusNotFound, response.ResponseBody{
+			Message: "Not found",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
+			Message: "Internal server error",
+			Error:   err.Error(),
+		})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.ResponseBody{
+		Message: "success",
+		Data:    job,
+	})
+}
+
+func (h *Handler) GetTagList(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{})
+		return
+	}
+	tags, err := h.service.ListTags(r.Context(), userID)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
+			Message: "Internal server error",
+			Error:   err.Error(),
+		})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.ResponseBody{
+		Message: "success",
+		Data:    tags,
+	})
+}
+
+func (h *Handler) GetCatImages(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{})
+		return
+	}
+	params := mux.Vars(r)
+	id := params["id"]
+	images, err := h.service.ListImages(r.Context(), id, userID)
+	if errors.Is(err, ErrCatNotFound) {
+		response.JSON(w, http.StatusNotFound, response.ResponseBody{
+			Message: "Not found",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
+			Message: "Internal server error",
+			Error:   err.Error(),
+		})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.ResponseBody{
+		Message: "success",
+		Data:    images,
+	})
+}
+
+func (h *Handler) AddCatImage(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{})
+		return
+	}
+
+	var req AddCatImagePayload
+
+	err = request.DecodeJSON(w, r, &req)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
+			Message: "Failed to decode JSON",
+			Error:   err.Error(),
+		})
+		return
+	}
+	params := mux.Vars(r)
+	id := params["id"]
+	image, err := h.service.AddImage(r.Context(), req, id, userID)
+	if errors.Is(err, ErrValidationFailed) {
+		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
+			Message: "Bad request",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, ErrCatNotFound) {
+		response.JSON(w, http.StatusNotFound, response.ResponseBody{
+			Message: "Not found",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
+			Message: "Internal server error",
+			Error:   err.Error(),
+		})
+		return
+	}
+	response.JSON(w, http.StatusCreated, response.ResponseBody{
+		Message: "success",
+		Data:    image,
+	})
+}
+
+func (h *Handler) ReorderCatImages(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{})
+		return
+	}
+
+	var req ReorderCatImagesPayload
+
+	err = request.DecodeJSON(w, r, &req)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
+			Message: "Failed to decode JSON",
+			Error:   err.Error(),
+		})
+		return
+	}
+	params := mux.Vars(r)
+	id := params["id"]
+	images, err := h.service.ReorderImages(r.Context(), req, id, userID)
+	if errors.Is(err, ErrValidationFailed) || errors.Is(err, ErrInvalidImageOrder) {
+		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
+			Message: "Bad request",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, ErrCatNotFound) {
+		response.JSON(w, http.StatusNotFound, response.ResponseBody{
+			Message: "Not found",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
+			Message: "Internal server error",
+			Error:   err.Error(),
+		})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.ResponseBody{
+		Message: "success",
+		Data:    images,
+	})
+}
+
+func (h *Handler) SetCatCoverImage(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{})
+		return
+	}
+	params := mux.Vars(r)
+	id := params["id"]
+	imageID := params["imageId"]
+	err = h.service.SetCoverImage(r.Context(), id, imageID, userID)
+	if errors.Is(err, ErrCatNotFound) || errors.Is(err, ErrImageNotFound) {
+		response.JSON(w, http.StatusNotFound, response.ResponseBody{
+			Message: "Not found",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
+			Message: "Internal server error",
+			Error:   err.Error(),
+		})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.ResponseBody{
+		Message: "success",
+	})
+}
+
+func (h *Handler) UpdateCatImage(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{})
+		return
+	}
+
+	var req UpdateCatImagePayload
+
+	err = request.DecodeJSON(w, r, &req)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
+			Message: "Failed to decode JSON",
+			Error:   err.Error(),
+		})
+		return
+	}
+	params := mux.Vars(r)
+	id := params["id"]
+	imageID := params["imageId"]
+	err = h.service.UpdateImage(r.Context(), req, id, imageID, userID)
+	if errors.Is(err, ErrValidationFailed) {
+		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
+			Message: "Bad request",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, ErrCatNotFound) || errors.Is(err, ErrImageNotFound) {
+		response.JSON(w, http.StatusNotFound, response.ResponseBody{
+			Message: "Not found",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
+			Message: "Internal server error",
+			Error:   err.Error(),
+		})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.ResponseBody{
+		Message: "success",
+	})
+}
+
+func (h *Handler) DeleteCatImage(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{})
+		return
+	}
+	params := mux.Vars(r)
+	id := params["id"]
+	imageID := params["imageId"]
+	err = h.service.DeleteImage(r.Context(), id, imageID, userID)
+	if errors.Is(err, ErrLastImage) {
+		response.JSON(w, http.StatusConflict, response.ResponseBody{
+			Message: "Conflict",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, ErrCatNotFound) || errors.Is(err, ErrImageNotFound) {
+		response.JSON(w, http.StatusNotFound, response.ResponseBody{
+			Message: "Not found",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
+			Message: "Internal server error",
+			Error:   err.Error(),
+		})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.ResponseBody{
+		Message: "success",
+	})
+}
+
 func getUserID(r *http.Request) (int64, error) {
 	if authValue, ok := r.Context().Value(middleware.ContextAuthKey{}).(string); ok {
 		return strconv.ParseInt(authValue, 10, 64)
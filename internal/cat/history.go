@@ -0,0 +1,106 @@
+package cat
+
+import (
+	"slices"
+	"time"
+)
+
+type VersionAction string
+
+const (
+	VersionCreate VersionAction = "create"
+	VersionUpdate VersionAction = "update"
+	VersionDelete VersionAction = "delete"
+)
+
+// CatSnapshot holds the owner editable fields of a cat at a given version.
+type CatSnapshot struct {
+	Name        string   `json:"name"`
+	Race        CatRace  `json:"race"`
+	Sex         CatSex   `json:"sex"`
+	AgeInMonth  int      `json:"ageInMonth"`
+	Description string   `json:"description"`
+	ImageUrls   []string `json:"imageUrls"`
+	Tags        []string `json:"tags"`
+	City        string   `json:"city"`
+	// the cover and the caption of every image, in gallery order. snapshots taken before the gallery
+	// was versioned have no captions
+	CoverURL      string   `json:"coverUrl"`
+	ImageCaptions []string `json:"imageCaptions"`
+}
+
+type CatVersion struct {
+	CatUID      string
+	Version     int
+	Action      VersionAction
+	ActorUserID *int64
+	ActorName   string
+	Snapshot    CatSnapshot
+	CreatedAt   time.Time
+}
+
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+func snapshotOf(cat *Cat) CatSnapshot {
+	snapshot := CatSnapshot{
+		Name:        cat.Name,
+		Race:        cat.Race,
+		Sex:         cat.Sex,
+		AgeInMonth:  cat.Age,
+		Description: cat.Description,
+		ImageUrls:   cat.ImageURLS,
+	}
+	if snapshot.ImageUrls == nil {
+		snapshot.ImageUrls = []string{}
+	}
+	snapshot.Tags = cat.Tags
+	if snapshot.Tags == nil {
+		snapshot.Tags = []string{}
+	}
+	if cat.Location != nil {
+		snapshot.City = cat.Location.City
+	}
+	snapshot.ImageCaptions = make([]string, len(cat.Images))
+	for i, image := range cat.Images {
+		snapshot.ImageCaptions[i] = image.Caption
+		if image.IsPrimary {
+			snapshot.CoverURL = image.URL
+		}
+	}
+	return snapshot
+}
+
+// diffSnapshots lists the fields changed between two versions; a nil prev means every field is new.
+func diffSnapshots(prev *CatSnapshot, next CatSnapshot) []FieldChange {
+	initial := prev == nil
+	if initial {
+		prev = &CatSnapshot{}
+	}
+	changes := make([]FieldChange, 0)
+	add := func(field string, from, to any, changed bool) {
+		if initial {
+			changes = append(changes, FieldChange{Field: field, To: to})
+			return
+		}
+		if changed {
+			changes = append(changes, FieldChange{Field: field, From: from, To: to})
+		}
+	}
+	add("name", prev.Name, next.Name, prev.Name != next.Name)
+	add("race", prev.Race, next.Race, prev.Race != next.Race)
+	add("sex", prev.Sex, next.Sex, prev.Sex != next.Sex)
+	add("ageInMonth", prev.AgeInMonth, next.AgeInMonth, prev.AgeInMonth != next.AgeInMonth)
+	add("description", prev.Description, next.Description, prev.Description != next.Description)
+	add("imageUrls", prev.ImageUrls, next.ImageUrls, !slices.Equal(prev.ImageUrls, next.ImageUrls))
+	add("tags", prev.Tags, next.Tags, !slices.Equal(prev.Tags, next.Tags))
+	add("city", prev.City, next.City, prev.City != next.City)
+	if initial || prev.ImageCaptions != nil {
+		add("coverUrl", prev.CoverURL, next.CoverURL, prev.CoverURL != next.CoverURL)
+		add("imageCaptions", prev.ImageCaptions, next.ImageCaptions, !slices.Equal(prev.ImageCaptions, next.ImageCaptions))
+	}
+	return changes
+}
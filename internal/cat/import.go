@@ -0,0 +1,200 @@
+package cat
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/citadel-corp/cats-social/internal/common/geo"
+)
+
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+
+	// separate multiple image urls or tags inside a single csv column
+	imageURLSeparator = "|"
+	tagSeparator      = "|"
+	maxImportRows     = 10000
+)
+
+var importCSVColumns = []string{"name", "race", "sex", "ageInMonth", "description", "imageUrls", "tags", "city", "latitude", "longitude", "visibility"}
+
+// ImportRow is a single parsed row of an import file. Err is set when the row could not be parsed.
+type ImportRow struct {
+	Line    int
+	Payload CreateUpdateCatPayload
+	Err     error
+}
+
+// ImportFormat picks the import format from an explicit format parameter or the request content type.
+func ImportFormat(format, contentType string) string {
+	switch strings.ToLower(format) {
+	case ImportFormatCSV:
+		return ImportFormatCSV
+	case ImportFormatNDJSON, "jsonl":
+		return ImportFormatNDJSON
+	}
+	switch {
+	case strings.HasPrefix(contentType, "text/csv"):
+		return ImportFormatCSV
+	case strings.HasPrefix(contentType, "application/x-ndjson"), strings.HasPrefix(contentType, "application/jsonl"):
+		return ImportFormatNDJSON
+	}
+	return ""
+}
+
+// ParseImportRows reads every row of a csv or ndjson import file. Malformed rows are reported on the row
+// itself; only unreadable files return an error.
+func ParseImportRows(r io.Reader, format string) ([]ImportRow, error) {
+	var rows []ImportRow
+	var err error
+	switch format {
+	case ImportFormatCSV:
+		rows, err = parseCSVRows(r)
+	case ImportFormatNDJSON:
+		rows, err = parseNDJSONRows(r)
+	default:
+		return nil, ErrImportFormatUnsupported
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("import file has no rows")
+	}
+	if len(rows) > maxImportRows {
+		return nil, fmt.Errorf("import file has more than %d rows", maxImportRows)
+	}
+	return rows, nil
+}
+
+func parseCSVRows(r io.Reader) ([]ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("import file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		known := false
+		for _, column := range importCSVColumns {
+			if strings.EqualFold(name, column) {
+				columns[column] = i
+				known = true
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown csv column %q", name)
+		}
+	}
+
+	rows := make([]ImportRow, 0)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		// a malformed row fails on its own, the reader carries on with the next one
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rows = append(rows, ImportRow{Line: parseErr.Line, Err: err})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		payload, err := csvPayload(record, columns)
+		rows = append(rows, ImportRow{Line: line, Payload: payload, Err: err})
+	}
+	return rows, nil
+}
+
+func csvPayload(record []string, columns map[string]int) (CreateUpdateCatPayload, error) {
+	get := func(column string) string {
+		if i, ok := columns[column]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	payload := CreateUpdateCatPayload{
+		Name:        get("name"),
+		Race:        CatRace(get("race")),
+		Sex:         CatSex(get("sex")),
+		Description: get("description"),
+		Visibility:  CatVisibility(get("visibility")),
+	}
+	if age := get("ageInMonth"); age != "" {
+		ageInMonth, err := strconv.Atoi(age)
+		if err != nil {
+			return payload, fmt.Errorf("ageInMonth: %w", err)
+		}
+		payload.AgeInMonth = ageInMonth
+	}
+	if imageURLs := get("imageUrls"); imageURLs != "" {
+		payload.ImageURLS = strings.Split(imageURLs, imageURLSeparator)
+		for i := range payload.ImageURLS {
+			payload.ImageURLS[i] = strings.TrimSpace(payload.ImageURLS[i])
+		}
+	}
+
+	if tags := get("tags"); tags != "" {
+		payload.Tags = strings.Split(tags, tagSeparator)
+	}
+
+	city, lat, lng := get("city"), get("latitude"), get("longitude")
+	if city == "" && lat == "" && lng == "" {
+		return payload, nil
+	}
+	payload.Location = &geo.LocationPayload{City: city}
+	if lat != "" {
+		latitude, err := strconv.ParseFloat(lat, 64)
+		if err != nil {
+			return payload, fmt.Errorf("latitude: %w", err)
+		}
+		payload.Location.Latitude = &latitude
+	}
+	if lng != "" {
+		longitude, err := strconv.ParseFloat(lng, 64)
+		if err != nil {
+			return payload, fmt.Errorf("longitude: %w", err)
+		}
+		payload.Location.Longitude = &longitude
+	}
+	return payload, nil
+}
+
+func parseNDJSONRows(r io.Reader) ([]ImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	rows := make([]ImportRow, 0)
+	line := 0
+	for scanner.Scan() {
+		line += 1
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		var payload CreateUpdateCatPayload
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		err := dec.Decode(&payload)
+		rows = append(rows, ImportRow{Line: line, Payload: payload, Err: err})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
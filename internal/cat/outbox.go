@@ -0,0 +1,66 @@
+package cat
+
+import (
+	"context"
+
+	"github.com/citadel-corp/cats-social/internal/common/db"
+	"github.com/citadel-corp/cats-social/internal/event"
+	"github.com/citadel-corp/cats-social/internal/outbox"
+)
+
+// OutboxAggregate is the aggregate type of cat domain events, identified by the cat UID.
+const OutboxAggregate = "cat"
+
+// outboxTypes is the domain event recorded for each change to a cat.
+var outboxTypes = map[VersionAction]outbox.Type{
+	VersionCreate: outbox.CatCreated,
+	VersionUpdate: outbox.CatUpdated,
+	VersionDelete: outbox.CatDeleted,
+}
+
+// userEventTypes is the event the owner gets for each cat domain event.
+var userEventTypes = map[outbox.Type]event.Type{
+	outbox.CatCreated:     event.CatCreated,
+	outbox.CatUpdated:     event.CatUpdated,
+	outbox.CatDeleted:     event.CatDeleted,
+	outbox.CatTransferred: event.CatTransferred,
+}
+
+// OutboxEvent is the payload of the cat domain events.
+type OutboxEvent struct {
+	ID          string `json:"id"`
+	UserID      int64  `json:"userId"`
+	Name        string `json:"name"`
+	Version     int    `json:"version"`
+	ActorUserID int64  `json:"actorUserId"`
+}
+
+// recordEvent writes the domain event of a change to cat in the transaction making it.
+func recordEvent(ctx context.Context, q db.Querier, cat *Cat, version int, action VersionAction, actorID int64) error {
+	return outbox.Record(ctx, q, OutboxAggregate, cat.UID, outboxTypes[action], OutboxEvent{
+		ID:          cat.UID,
+		UserID:      cat.UserID,
+		Name:        cat.Name,
+		Version:     version,
+		ActorUserID: actorID,
+	})
+}
+
+// UserEvents turns a cat domain event into the events its owner is told about, none for events without
+// a user facing counterpart.
+func UserEvents(e outbox.Event) ([]event.Event, error) {
+	typ, ok := userEventTypes[e.Type]
+	if !ok {
+		return nil, nil
+	}
+	var payload OutboxEvent
+	err := e.Decode(&payload)
+	if err != nil {
+		return nil, err
+	}
+	return []event.Event{{
+		Type:   typ,
+		UserID: payload.UserID,
+		Data:   CatEventData{ID: payload.ID, Name: payload.Name},
+	}}, nil
+}
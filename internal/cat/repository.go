@@ -3,22 +3,60 @@ package cat
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/citadel-corp/cats-social/internal/common/db"
+	"github.com/citadel-corp/cats-social/internal/common/geo"
+	"github.com/citadel-corp/cats-social/internal/common/id"
 	"github.com/lib/pq"
 )
 
 type Repository interface {
 	List(ctx context.Context, req ListCatPayload, userID int64) ([]*Cat, error)
+	CountTags(ctx context.Context, req ListCatPayload, userID int64) ([]TagCount, error)
+	CountFacets(ctx context.Context, req ListCatPayload, userID int64) ([]FacetCount, error)
+	ListTags(ctx context.Context, userID int64) ([]Tag, error)
 	GetByUIDAndUserID(ctx context.Context, id string, userID int64) (*Cat, error)
 	GetByIDAndUserID(ctx context.Context, id int64, userID int64) (*Cat, error)
-	GetByUID(ctx context.Context, uid string) (*Cat, error)
+	GetByUID(ctx context.Context, uid string, viewerID int64) (*Cat, error)
 	Create(ctx context.Context, cat *Cat) (*Cat, error)
+	CreateMany(ctx context.Context, cats []*Cat) ([]*Cat, error)
 	Update(ctx context.Context, cat *Cat) error
 	Delete(ctx context.Context, id string, userID int64) error
+	ListVersions(ctx context.Context, uid string) ([]CatVersion, error)
+	AddFavorite(ctx context.Context, catID int64, userID int64) error
+	RemoveFavorite(ctx context.Context, catID int64, userID int64) error
+	GetOwnerLocation(ctx context.Context, userID int64) (*geo.Location, error)
+	CountByUserID(ctx context.Context, userID int64) (int, error)
+	EachByUserID(ctx context.Context, userID int64, fn func(*Cat) error) error
+	ListImages(ctx context.Context, catID int64) ([]CatImage, error)
+	AddImage(ctx context.Context, cat *Cat, image *CatImage) error
+	ReorderImages(ctx context.Context, cat *Cat, imageUIDs []string) error
+	SetCoverImage(ctx context.Context, cat *Cat, imageUID string) error
+	UpdateImageCaption(ctx context.Context, cat *Cat, imageUID string, caption string) error
+	DeleteImage(ctx context.Context, cat *Cat, imageUID string) error
+}
+
+// HasMatchedSQL is the SQL telling whether the cat with the id in column catID is currently in an active
+// match. Matching isn't stored on the cat, it follows the cat's pairings.
+func HasMatchedSQL(catID string) string {
+	return fmt.Sprintf("(EXISTS (SELECT 1 FROM cat_pairings cp WHERE cp.cat_id = %s AND cp.ended_at IS NULL))", catID)
+}
+
+// catColumns are the columns read by scanCat, in order. Image urls come from the gallery, in gallery order.
+var catColumns = "id, uid, user_id, name, race, sex, age_in_month, description, " + HasMatchedSQL("cats.id") + ", " +
+	"ARRAY(SELECT ci.url FROM cat_images ci WHERE ci.cat_id = cats.id ORDER BY ci.position), " +
+	"latitude, longitude, city, visibility, version, created_at, " +
+	"ARRAY(SELECT t.slug FROM cat_tags ct JOIN tags t ON t.id = ct.tag_id WHERE ct.cat_id = cats.id ORDER BY t.slug)"
+
+const catImageColumns = "id, uid, cat_id, url, position, caption, is_primary, created_at"
+
+type rowScanner interface {
+	Scan(dest ...any) error
 }
 
 type dbRepository struct {
@@ -32,13 +70,12 @@ func NewRepository(db *db.DB) Repository {
 // GetByIDAndUserID implements Repository.
 func (d *dbRepository) GetByUIDAndUserID(ctx context.Context, uid string, userID int64) (*Cat, error) {
 	getUserQuery := `
-		SELECT id, uid, user_id, name, race, sex, age_in_month, description, has_matched, image_urls, created_at
+		SELECT ` + catColumns + `
 		FROM cats
 		WHERE uid = $1 AND user_id = $2;
 	`
 	row := d.db.DB().QueryRowContext(ctx, getUserQuery, uid, userID)
-	cat := &Cat{}
-	err := row.Scan(&cat.ID, &cat.UID, &cat.UserID, &cat.Name, &cat.Race, &cat.Sex, &cat.Age, &cat.Description, &cat.HasMatched, pq.Array(&cat.ImageURLS), &cat.CreatedAt)
+	cat, err := scanCat(row)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrCatNotFound
 	}
@@ -50,13 +87,12 @@ func (d *dbRepository) GetByUIDAndUserID(ctx context.Context, uid string, userID
 
 func (d *dbRepository) GetByIDAndUserID(ctx context.Context, id int64, userID int64) (*Cat, error) {
 	getUserQuery := `
-		SELECT id, uid, user_id, name, race, sex, age_in_month, description, has_matched, image_urls, created_at
+		SELECT ` + catColumns + `
 		FROM cats
 		WHERE id = $1 AND user_id = $2;
 	`
 	row := d.db.DB().QueryRowContext(ctx, getUserQuery, id, userID)
-	cat := &Cat{}
-	err := row.Scan(&cat.ID, &cat.UID, &cat.UserID, &cat.Name, &cat.Race, &cat.Sex, &cat.Age, &cat.Description, &cat.HasMatched, pq.Array(&cat.ImageURLS), &cat.CreatedAt)
+	cat, err := scanCat(row)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrCatNotFound
 	}
@@ -66,15 +102,15 @@ func (d *dbRepository) GetByIDAndUserID(ctx context.Context, id int64, userID in
 	return cat, nil
 }
 
-func (d *dbRepository) GetByUID(ctx context.Context, uid string) (*Cat, error) {
+// GetByUID implements Repository. Private cats are only found by their owner.
+func (d *dbRepository) GetByUID(ctx context.Context, uid string, viewerID int64) (*Cat, error) {
 	getUserQuery := `
-		SELECT id, uid, user_id, name, race, sex, age_in_month, description, has_matched, image_urls, created_at
+		SELECT ` + catColumns + `
 		FROM cats
-		WHERE uid = $1;
+		WHERE uid = $1 AND (visibility <> 'private' OR user_id = $2);
 	`
-	row := d.db.DB().QueryRowContext(ctx, getUserQuery, uid)
-	cat := &Cat{}
-	err := row.Scan(&cat.ID, &cat.UID, &cat.UserID, &cat.Name, &cat.Race, &cat.Sex, &cat.Age, &cat.Description, &cat.HasMatched, pq.Array(&cat.ImageURLS), &cat.CreatedAt)
+	row := d.db.DB().QueryRowContext(ctx, getUserQuery, uid, viewerID)
+	cat, err := scanCat(row)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrCatNotFound
 	}
@@ -86,135 +122,970 @@ func (d *dbRepository) GetByUID(ctx context.Context, uid string) (*Cat, error) {
 
 // List implements Repository.
 func (d *dbRepository) List(ctx context.Context, req ListCatPayload, userID int64) ([]*Cat, error) {
-	paramNo := 1
-	listQuery := "SELECT * FROM cats WHERE "
-	params := make([]interface{}, 0)
+	filter := newListFilter(req, userID)
+	listQuery := fmt.Sprintf(`
+		SELECT c.id, c.uid, c.user_id, c.name, c.race, c.sex, c.age_in_month, c.description, %s, c.city, c.visibility, c.version, c.created_at,
+		ARRAY(SELECT t.slug FROM cat_tags ct JOIN tags t ON t.id = ct.tag_id WHERE ct.cat_id = c.id ORDER BY t.slug),
+		(SELECT COUNT(*) FROM cat_favorites f WHERE f.cat_id = c.id),
+		EXISTS (SELECT 1 FROM cat_favorites f WHERE f.cat_id = c.id AND f.user_id = $1),
+		%s AS distance
+		FROM cats c
+		WHERE %s`, HasMatchedSQL("c.id"), filter.distance, filter.where())
+	orderBy := "c.created_at DESC"
+	if req.Origin != nil && req.Sort == SortDistance {
+		orderBy = "distance ASC NULLS LAST, c.created_at DESC"
+	}
+	listQuery += fmt.Sprintf(" ORDER BY %s LIMIT %d OFFSET %d;", orderBy, req.Limit, req.Offset)
+	rows, err := d.db.DB().QueryContext(ctx, listQuery, filter.params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make([]*Cat, 0)
+	for rows.Next() {
+		cat := &Cat{}
+		var city sql.NullString
+		var distance sql.NullFloat64
+		err = rows.Scan(&cat.ID, &cat.UID, &cat.UserID, &cat.Name, &cat.Race, &cat.Sex, &cat.Age, &cat.Description, &cat.HasMatched, &city, &cat.Visibility, &cat.Version, &cat.CreatedAt,
+			pq.Array(&cat.Tags), &cat.FavoriteCount, &cat.IsFavorited, &distance)
+		if err != nil {
+			return nil, err
+		}
+		if city.Valid {
+			cat.Location = &geo.Location{City: city.String}
+		}
+		if distance.Valid {
+			cat.DistanceKm = &distance.Float64
+		}
+		res = append(res, cat)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	err = d.loadImages(ctx, res)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// CountTags implements Repository. Counts how many of the cats matching req carry each tag, ignoring paging.
+func (d *dbRepository) CountTags(ctx context.Context, req ListCatPayload, userID int64) ([]TagCount, error) {
+	filter := newListFilter(req, userID)
+	countQuery := fmt.Sprintf(`
+		SELECT t.slug, t.name, COUNT(*)
+		FROM cats c
+		JOIN cat_tags ct ON ct.cat_id = c.id
+		JOIN tags t ON t.id = ct.tag_id
+		WHERE %s
+		GROUP BY t.slug, t.name
+		ORDER BY COUNT(*) DESC, t.slug ASC;`, filter.where())
+	rows, err := d.db.DB().QueryContext(ctx, countQuery, filter.params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make([]TagCount, 0)
+	for rows.Next() {
+		tc := TagCount{}
+		err = rows.Scan(&tc.Slug, &tc.Name, &tc.Count)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, tc)
+	}
+	return res, rows.Err()
+}
+
+// CountFacets implements Repository. Every facet is counted over the cats matching req without the facet's
+// own filter, so picking a value does not hide the other values of the same facet. All facets are computed by
+// a single query: the cats matching the shared filters are read once and each facet aggregates over them.
+func (d *dbRepository) CountFacets(ctx context.Context, req ListCatPayload, userID int64) ([]FacetCount, error) {
+	if len(req.FacetKeys) == 0 {
+		return []FacetCount{}, nil
+	}
+	filter := newListFilter(req, userID)
+	counted := make(map[string]bool, len(req.FacetKeys))
+	for _, facet := range req.FacetKeys {
+		if _, ok := facetColumns[facet]; ok {
+			counted[facet] = true
+		}
+	}
+	columns := make([]string, 0, len(req.FacetKeys))
+	counts := make([]string, 0, len(req.FacetKeys))
+	for _, facet := range req.FacetKeys {
+		column, ok := facetColumns[facet]
+		if !ok {
+			continue
+		}
+		columns = append(columns, fmt.Sprintf("%s AS %s_value, (%s) AS %s_ok",
+			column, facet, filter.whereFacet(func(f string) bool { return f == facet }), facet))
+
+		// a facet is narrowed by every other counted facet's filter, filters on facets that aren't counted
+		// are part of base already
+		others := make([]string, 0, len(req.FacetKeys)-1)
+		for _, other := range req.FacetKeys {
+			if counted[other] && other != facet {
+				others = append(others, other+"_ok")
+			}
+		}
+		where := "TRUE"
+		if len(others) > 0 {
+			where = strings.Join(others, " AND ")
+		}
+		counts = append(counts, fmt.Sprintf("SELECT '%[1]s', %[1]s_value, COUNT(*) FROM base WHERE %[2]s GROUP BY %[1]s_value",
+			facet, where))
+	}
+	if len(columns) == 0 {
+		return []FacetCount{}, nil
+	}
+	facetQuery := fmt.Sprintf(`
+		WITH base AS (
+			SELECT %s
+			FROM cats c
+			WHERE %s
+		)
+		%s;`,
+		strings.Join(columns, ", "),
+		filter.whereFacet(func(f string) bool { return !counted[f] }),
+		strings.Join(counts, " UNION ALL "))
+	rows, err := d.db.DB().QueryContext(ctx, facetQuery, filter.params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make([]FacetCount, 0)
+	for rows.Next() {
+		fc := FacetCount{}
+		err = rows.Scan(&fc.Facet, &fc.Value, &fc.Count)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, fc)
+	}
+	return res, rows.Err()
+}
+
+// facetColumns are the values each facet is grouped by.
+var facetColumns = map[string]string{
+	FacetRace:       "c.race::TEXT",
+	FacetSex:        "c.sex::TEXT",
+	FacetHasMatched: HasMatchedSQL("c.id") + "::TEXT",
+	FacetAge: fmt.Sprintf(`CASE
+				WHEN c.age_in_month < %d THEN '%s'
+				WHEN c.age_in_month < %d THEN '%s'
+				WHEN c.age_in_month < %d THEN '%s'
+				ELSE '%s' END`,
+		youngMinAge, AgeKitten, adultMinAge, AgeYoung, seniorMinAge, AgeAdult, AgeSenior),
+}
+
+// listFilter builds the WHERE clause of a cat listing. $1 is always the viewer's user id, so
+// visibility and favorite checks can refer to it.
+type listFilter struct {
+	conditions []listCondition
+	params     []any
+	distance   string
+}
+
+// listCondition is a single condition of a listFilter. Conditions on a facet are left out when
+// counting that facet.
+type listCondition struct {
+	facet string
+	sql   string
+}
+
+func newListFilter(req ListCatPayload, userID int64) *listFilter {
+	f := &listFilter{
+		params:   []any{userID},
+		distance: "NULL::DOUBLE PRECISION",
+	}
+	if req.Origin != nil {
+		f.distance = geo.DistanceSQL("c.latitude", "c.longitude", f.param(req.Origin.Lat), f.param(req.Origin.Lng))
+	}
 	if req.ID != "" {
-		listQuery += fmt.Sprintf("uid = $%d AND ", paramNo)
-		paramNo += 1
-		params = append(params, req.ID)
+		f.add("c.uid = %s", req.ID)
 	}
 	if req.Race != "" {
-		listQuery += fmt.Sprintf("race = $%d AND ", paramNo)
-		paramNo += 1
-		params = append(params, req.Race)
+		f.addFacet(FacetRace, "c.race = %s", req.Race)
 	}
 	if req.Sex != "" {
-		listQuery += fmt.Sprintf("sex = $%d AND ", paramNo)
-		paramNo += 1
-		params = append(params, req.Sex)
+		f.addFacet(FacetSex, "c.sex = %s", req.Sex)
 	}
 	switch req.HasMatchedType {
 	case HasMatched:
-		listQuery += fmt.Sprintf("has_matched = $%d AND ", paramNo)
-		paramNo += 1
-		params = append(params, true)
+		f.addFacet(FacetHasMatched, HasMatchedSQL("c.id")+" = %s", true)
 	case HasNotMatched:
-		listQuery += fmt.Sprintf("has_matched = $%d AND ", paramNo)
-		paramNo += 1
-		params = append(params, false)
+		f.addFacet(FacetHasMatched, HasMatchedSQL("c.id")+" = %s", false)
 	}
 	switch req.AgeSearchType {
 	case MoreThan:
-		listQuery += fmt.Sprintf("age_in_month > $%d AND ", paramNo)
-		paramNo += 1
-		params = append(params, req.Age)
+		f.addFacet(FacetAge, "c.age_in_month > %s", req.Age)
 	case LessThan:
-		listQuery += fmt.Sprintf("age_in_month < $%d AND ", paramNo)
-		paramNo += 1
-		params = append(params, req.Age)
+		f.addFacet(FacetAge, "c.age_in_month < %s", req.Age)
 	case EqualTo:
-		listQuery += fmt.Sprintf("age_in_month = $%d AND ", paramNo)
-		paramNo += 1
-		params = append(params, req.Age)
+		f.addFacet(FacetAge, "c.age_in_month = %s", req.Age)
 	}
 
+	if req.ID != "" {
+		// unlisted cats are reachable by their id
+		f.add("(c.visibility <> 'private' OR c.user_id = $1)")
+	} else {
+		f.add("(c.visibility = 'public' OR c.user_id = $1)")
+	}
 	if req.Owned {
-		listQuery += fmt.Sprintf("user_id = $%d AND ", paramNo)
-		params = append(params, userID)
+		f.add("c.user_id = $1")
+	}
+	if req.NotOwned {
+		f.add("c.user_id <> $1")
+	}
+	if len(req.ExcludeIDs) > 0 {
+		f.add("c.id <> ALL(%s)", pq.Array(req.ExcludeIDs))
+	}
+	if req.Favorited {
+		f.add("EXISTS (SELECT 1 FROM cat_favorites f WHERE f.cat_id = c.id AND f.user_id = $1)")
+	}
+	if req.Origin != nil && req.RadiusKm > 0 {
+		minLat, maxLat, minLng, maxLng, ok := geo.BoundingBox(*req.Origin, req.RadiusKm)
+		f.add("c.latitude BETWEEN %s AND %s", minLat, maxLat)
+		if ok {
+			f.add("c.longitude BETWEEN %s AND %s", minLng, maxLng)
+		}
+		f.add(f.distance+" <= %s", req.RadiusKm)
+	}
+	if len(req.TagSlugs) > 0 {
+		tagged := "SELECT ct.cat_id FROM cat_tags ct JOIN tags t ON t.id = ct.tag_id WHERE t.slug = ANY(%s)"
+		if req.TagMatch == TagMatchAll {
+			tagged += fmt.Sprintf(" GROUP BY ct.cat_id HAVING COUNT(*) = %d", len(req.TagSlugs))
+		}
+		f.add("c.id IN ("+tagged+")", pq.Array(req.TagSlugs))
 	}
 	if req.Search != "" {
-		listQuery += fmt.Sprintf("name LIKE '%%%s%%' AND ", req.Search)
+		f.add("c.name LIKE %s", "%"+req.Search+"%")
 	}
-	if strings.HasSuffix(listQuery, "AND ") {
-		listQuery, _ = strings.CutSuffix(listQuery, "AND ")
+	return f
+}
+
+// param binds value to the next positional parameter and returns its placeholder.
+func (f *listFilter) param(value any) string {
+	f.params = append(f.params, value)
+	return fmt.Sprintf("$%d", len(f.params))
+}
+
+// add appends a condition, binding each value to the matching %s in condition.
+func (f *listFilter) add(condition string, values ...any) {
+	f.addFacet("", condition, values...)
+}
+
+// addFacet appends a condition that filters on facet.
+func (f *listFilter) addFacet(facet string, condition string, values ...any) {
+	placeholders := make([]any, len(values))
+	for i, value := range values {
+		placeholders[i] = f.param(value)
 	}
-	listQuery += fmt.Sprintf(" ORDER BY created_at DESC LIMIT %d OFFSET %d;", req.Limit, req.Offset)
-	if strings.Contains(listQuery, "WHERE  ORDER") {
-		listQuery = strings.Replace(listQuery, "WHERE  ORDER", "ORDER", 1)
+	f.conditions = append(f.conditions, listCondition{facet: facet, sql: fmt.Sprintf(condition, placeholders...)})
+}
+
+func (f *listFilter) where() string {
+	return f.whereFacet(func(string) bool { return true })
+}
+
+// whereFacet joins the conditions whose facet matches keep.
+func (f *listFilter) whereFacet(keep func(facet string) bool) string {
+	conditions := make([]string, 0, len(f.conditions))
+	for _, c := range f.conditions {
+		if keep(c.facet) {
+			conditions = append(conditions, c.sql)
+		}
+	}
+	if len(conditions) == 0 {
+		return "TRUE"
 	}
-	rows, err := d.db.DB().QueryContext(ctx, listQuery, params...)
+	return strings.Join(conditions, " AND ")
+}
+
+// loadImages fills the gallery of every cat with a single query.
+func (d *dbRepository) loadImages(ctx context.Context, cats []*Cat) error {
+	if len(cats) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(cats))
+	byID := make(map[int64]*Cat, len(cats))
+	for i, cat := range cats {
+		ids[i] = cat.ID
+		byID[cat.ID] = cat
+		cat.ImageURLS = make([]string, 0)
+		cat.Images = make([]CatImage, 0)
+	}
+	listImagesQuery := `
+		SELECT ` + catImageColumns + `
+		FROM cat_images
+		WHERE cat_id = ANY($1)
+		ORDER BY cat_id, position;
+	`
+	rows, err := d.db.DB().QueryContext(ctx, listImagesQuery, pq.Array(ids))
 	if err != nil {
-		return nil, err
+		return err
 	}
-	res := make([]*Cat, 0)
+	defer rows.Close()
 	for rows.Next() {
-		cat := &Cat{}
-		err = rows.Scan(&cat.ID, &cat.UID, &cat.UserID, &cat.Name, &cat.Race, &cat.Sex, &cat.Age, &cat.Description, &cat.HasMatched, pq.Array(&cat.ImageURLS), &cat.CreatedAt)
+		image, err := scanCatImage(rows)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		res = append(res, cat)
+		cat := byID[image.CatID]
+		cat.Images = append(cat.Images, *image)
+		cat.ImageURLS = append(cat.ImageURLS, image.URL)
 	}
-	return res, nil
+	return rows.Err()
 }
 
 // Create implements Repository.
 func (d *dbRepository) Create(ctx context.Context, cat *Cat) (*Cat, error) {
+	var res *Cat
+	err := d.db.StartTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		res, err = createCat(ctx, tx, cat)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// CreateMany implements Repository.
+func (d *dbRepository) CreateMany(ctx context.Context, cats []*Cat) ([]*Cat, error) {
+	res := make([]*Cat, 0, len(cats))
+	err := d.db.StartTx(ctx, func(tx *sql.Tx) error {
+		for _, cat := range cats {
+			c, err := createCat(ctx, tx, cat)
+			if err != nil {
+				return err
+			}
+			res = append(res, c)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func createCat(ctx context.Context, q db.Querier, cat *Cat) (*Cat, error) {
 	createCatQuery := `
 		INSERT INTO cats (
-			uid, user_id, name, race, sex, age_in_month, description, has_matched, image_urls
+			uid, user_id, name, race, sex, age_in_month, description, latitude, longitude, city, visibility
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9
-		) RETURNING uid, created_at;
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+		) RETURNING id, uid, version, created_at;
 	`
-	row := d.db.DB().QueryRowContext(ctx, createCatQuery,
-		cat.UID, cat.UserID, cat.Name, cat.Race, cat.Sex, cat.Age, cat.Description, cat.HasMatched, pq.Array(cat.ImageURLS))
+	lat, lng, city := locationArgs(cat.Location)
+	row := q.QueryRowContext(ctx, createCatQuery,
+		cat.UID, cat.UserID, cat.Name, cat.Race, cat.Sex, cat.Age, cat.Description, lat, lng, city, cat.Visibility)
 	c := &Cat{}
-	err := row.Scan(&c.UID, &c.CreatedAt)
+	err := row.Scan(&c.ID, &c.UID, &c.Version, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = syncImages(ctx, q, c.ID, cat.ImageURLS)
+	if err != nil {
+		return nil, err
+	}
+	err = syncTags(ctx, q, c.ID, cat.Tags, cat.UserID)
+	if err != nil {
+		return nil, err
+	}
+	cat.Images, err = listImages(ctx, q, c.ID)
 	if err != nil {
 		return nil, err
 	}
 
+	err = insertVersion(ctx, q, cat, c.Version, VersionCreate, cat.UserID)
+	if err != nil {
+		return nil, err
+	}
 	return c, nil
 }
 
-// Update implements Repository.
+// Update implements Repository. Every update bumps the cat's version and records a snapshot of it.
 func (d *dbRepository) Update(ctx context.Context, cat *Cat) error {
-	updateQuery := `
-		UPDATE cats
-		SET name = $1,
-		race = $2,
-		sex = $3,
-		age_in_month = $4,
-		description = $5,
-		has_matched = $6,
-		image_urls = $7
-		WHERE uid = $8 AND user_id = $9
-	`
-	_, err := d.db.DB().ExecContext(ctx, updateQuery, cat.Name, cat.Race, cat.Sex, cat.Age, cat.Description, cat.HasMatched, pq.Array(cat.ImageURLS), cat.UID, cat.UserID)
+	return d.db.StartTx(ctx, func(tx *sql.Tx) error {
+		updateQuery := `
+			UPDATE cats
+			SET name = $1,
+			race = $2,
+			sex = $3,
+			age_in_month = $4,
+			description = $5,
+			latitude = $6,
+			longitude = $7,
+			city = $8,
+			visibility = $9,
+			version = version + 1
+			WHERE uid = $10 AND user_id = $11
+			RETURNING id, version;
+		`
+		lat, lng, city := locationArgs(cat.Location)
+		var catID int64
+		var version int
+		err := tx.QueryRowContext(ctx, updateQuery, cat.Name, cat.Race, cat.Sex, cat.Age, cat.Description, lat, lng, city, cat.Visibility, cat.UID, cat.UserID).
+			Scan(&catID, &version)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCatNotFound
+		}
+		if err != nil {
+			return err
+		}
+		err = syncImages(ctx, tx, catID, cat.ImageURLS)
+		if err != nil {
+			return err
+		}
+		err = syncTags(ctx, tx, catID, cat.Tags, cat.UserID)
+		if err != nil {
+			return err
+		}
+		cat.Images, err = listImages(ctx, tx, catID)
+		if err != nil {
+			return err
+		}
+		return insertVersion(ctx, tx, cat, version, VersionUpdate, cat.UserID)
+	})
+}
+
+// Delete implements Repository. The last state of the cat is kept as its final version.
+func (d *dbRepository) Delete(ctx context.Context, uid string, userID int64) error {
+	return d.db.StartTx(ctx, func(tx *sql.Tx) error {
+		getCatQuery := `
+			SELECT ` + catColumns + `
+			FROM cats
+			WHERE uid = $1 and user_id = $2
+			FOR UPDATE;
+		`
+		cat, err := scanCat(tx.QueryRowContext(ctx, getCatQuery, uid, userID))
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCatNotFound
+		}
+		if err != nil {
+			return err
+		}
+		cat.Images, err = listImages(ctx, tx, cat.ID)
+		if err != nil {
+			return err
+		}
+		deleteCatQuery := `
+			DELETE FROM cats
+			WHERE id = $1;
+		`
+		_, err = tx.ExecContext(ctx, deleteCatQuery, cat.ID)
+		if err != nil {
+			return err
+		}
+		return insertVersion(ctx, tx, cat, cat.Version+1, VersionDelete, userID)
+	})
+}
+
+// ListVersions implements Repository.
+func (d *dbRepository) ListVersions(ctx context.Context, uid string) ([]CatVersion, error) {
+	listVersionQuery := `
+		SELECT v.cat_uid, v.version, v.action, v.actor_user_id, COALESCE(u.name, ''), v.snapshot, v.created_at
+		FROM cat_versions v
+		LEFT JOIN users u on v.actor_user_id = u.id
+		WHERE v.cat_uid = $1
+		ORDER BY v.version ASC;
+	`
+	rows, err := d.db.DB().QueryContext(ctx, listVersionQuery, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make([]CatVersion, 0)
+	for rows.Next() {
+		v := CatVersion{}
+		var snapshot []byte
+		err = rows.Scan(&v.CatUID, &v.Version, &v.Action, &v.ActorUserID, &v.ActorName, &snapshot, &v.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(snapshot, &v.Snapshot); err != nil {
+			return nil, err
+		}
+		res = append(res, v)
+	}
+	return res, rows.Err()
+}
+
+// insertVersion records a snapshot of the cat and the domain event of the change. Every change to a cat
+// goes through here, with the gallery of the cat loaded into its Images.
+func insertVersion(ctx context.Context, q db.Querier, cat *Cat, version int, action VersionAction, actorID int64) error {
+	snapshot, err := json.Marshal(snapshotOf(cat))
+	if err != nil {
+		return err
+	}
+	insertVersionQuery := `
+		INSERT INTO cat_versions (
+			cat_uid, version, action, actor_user_id, snapshot
+		) VALUES (
+			$1, $2, $3, $4, $5
+		);
+	`
+	_, err = q.ExecContext(ctx, insertVersionQuery, cat.UID, version, action, actorID, string(snapshot))
+	if err != nil {
+		return err
+	}
+	return recordEvent(ctx, q, cat, version, action, actorID)
+}
+
+// AddFavorite implements Repository.
+func (d *dbRepository) AddFavorite(ctx context.Context, catID int64, userID int64) error {
+	addFavoriteQuery := `
+		INSERT INTO cat_favorites (
+			user_id, cat_id
+		) VALUES (
+			$1, $2
+		) ON CONFLICT (user_id, cat_id) DO NOTHING;
+	`
+	_, err := d.db.DB().ExecContext(ctx, addFavoriteQuery, userID, catID)
+	return err
+}
+
+// RemoveFavorite implements Repository.
+func (d *dbRepository) RemoveFavorite(ctx context.Context, catID int64, userID int64) error {
+	removeFavoriteQuery := `
+		DELETE FROM cat_favorites
+		WHERE user_id = $1 AND cat_id = $2;
+	`
+	_, err := d.db.DB().ExecContext(ctx, removeFavoriteQuery, userID, catID)
+	return err
+}
+
+// GetOwnerLocation implements Repository.
+func (d *dbRepository) GetOwnerLocation(ctx context.Context, userID int64) (*geo.Location, error) {
+	getLocationQuery := `
+		SELECT latitude, longitude, city
+		FROM users
+		WHERE id = $1;
+	`
+	row := d.db.DB().QueryRowContext(ctx, getLocationQuery, userID)
+	var lat, lng sql.NullFloat64
+	var city sql.NullString
+	err := row.Scan(&lat, &lng, &city)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return scanLocation(lat, lng, city), nil
+}
+
+func scanCat(row rowScanner) (*Cat, error) {
+	cat := &Cat{}
+	var lat, lng sql.NullFloat64
+	var city sql.NullString
+	err := row.Scan(&cat.ID, &cat.UID, &cat.UserID, &cat.Name, &cat.Race, &cat.Sex, &cat.Age, &cat.Description, &cat.HasMatched, pq.Array(&cat.ImageURLS),
+		&lat, &lng, &city, &cat.Visibility, &cat.Version, &cat.CreatedAt, pq.Array(&cat.Tags))
+	if err != nil {
+		return nil, err
+	}
+	cat.Location = scanLocation(lat, lng, city)
+	return cat, nil
+}
+
+func scanLocation(lat, lng sql.NullFloat64, city sql.NullString) *geo.Location {
+	if !lat.Valid || !lng.Valid {
+		return nil
+	}
+	return &geo.Location{
+		Point: geo.Point{Lat: lat.Float64, Lng: lng.Float64},
+		City:  city.String,
+	}
+}
+
+func locationArgs(loc *geo.Location) (lat, lng, city interface{}) {
+	if loc == nil {
+		return nil, nil, nil
+	}
+	if loc.City != "" {
+		city = loc.City
+	}
+	return loc.Lat, loc.Lng, city
+}
+
+// CountByUserID implements Repository.
+func (d *dbRepository) CountByUserID(ctx context.Context, userID int64) (int, error) {
+	countQuery := `
+		SELECT COUNT(*)
+		FROM cats
+		WHERE user_id = $1;
+	`
+	var count int
+	err := d.db.DB().QueryRowContext(ctx, countQuery, userID).Scan(&count)
+	return count, err
+}
+
+// EachByUserID implements Repository. Rows are handed to fn one at a time so callers can stream them.
+func (d *dbRepository) EachByUserID(ctx context.Context, userID int64, fn func(*Cat) error) error {
+	listQuery := `
+		SELECT ` + catColumns + `
+		FROM cats
+		WHERE user_id = $1
+		ORDER BY created_at ASC;
+	`
+	rows, err := d.db.DB().QueryContext(ctx, listQuery, userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		cat, err := scanCat(rows)
+		if err != nil {
+			return err
+		}
+		if err = fn(cat); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ListImages implements Repository.
+func (d *dbRepository) ListImages(ctx context.Context, catID int64) ([]CatImage, error) {
+	return listImages(ctx, d.db.DB(), catID)
+}
+
+// AddImage implements Repository. The image is appended to the end of the gallery and becomes the cover
+// if the cat has none.
+func (d *dbRepository) AddImage(ctx context.Context, cat *Cat, image *CatImage) error {
+	return d.db.StartTx(ctx, func(tx *sql.Tx) error {
+		err := lockCat(ctx, tx, cat.ID)
+		if err != nil {
+			return err
+		}
+		addImageQuery := `
+			INSERT INTO cat_images (
+				uid, cat_id, url, position, caption, is_primary
+			) VALUES (
+				$1, $2, $3,
+				(SELECT COALESCE(MAX(position) + 1, 0) FROM cat_images WHERE cat_id = $2),
+				$4,
+				NOT EXISTS (SELECT 1 FROM cat_images WHERE cat_id = $2 AND is_primary)
+			) RETURNING ` + catImageColumns + `;
+		`
+		res, err := scanCatImage(tx.QueryRowContext(ctx, addImageQuery, image.UID, cat.ID, image.URL, image.Caption))
+		if err != nil {
+			return err
+		}
+		*image = *res
+		return bumpVersion(ctx, tx, cat.ID, cat.UserID)
+	})
+}
+
+// ReorderImages implements Repository. imageUIDs must list every image of the cat exactly once.
+func (d *dbRepository) ReorderImages(ctx context.Context, cat *Cat, imageUIDs []string) error {
+	return d.db.StartTx(ctx, func(tx *sql.Tx) error {
+		err := lockCat(ctx, tx, cat.ID)
+		if err != nil {
+			return err
+		}
+		images, err := listImages(ctx, tx, cat.ID)
+		if err != nil {
+			return err
+		}
+		if len(images) != len(imageUIDs) {
+			return ErrInvalidImageOrder
+		}
+		known := make(map[string]bool, len(images))
+		for _, image := range images {
+			known[image.UID] = true
+		}
+		for _, uid := range imageUIDs {
+			if !known[uid] {
+				return ErrInvalidImageOrder
+			}
+			delete(known, uid)
+		}
+		reorderQuery := `
+			UPDATE cat_images
+			SET position = o.position - 1
+			FROM unnest($1::TEXT[]) WITH ORDINALITY AS o(uid, position)
+			WHERE cat_images.uid = o.uid AND cat_images.cat_id = $2;
+		`
+		_, err = tx.ExecContext(ctx, reorderQuery, pq.Array(imageUIDs), cat.ID)
+		if err != nil {
+			return err
+		}
+		return bumpVersion(ctx, tx, cat.ID, cat.UserID)
+	})
+}
+
+// SetCoverImage implements Repository.
+func (d *dbRepository) SetCoverImage(ctx context.Context, cat *Cat, imageUID string) error {
+	return d.db.StartTx(ctx, func(tx *sql.Tx) error {
+		err := lockCat(ctx, tx, cat.ID)
+		if err != nil {
+			return err
+		}
+		var exists bool
+		err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM cat_images WHERE uid = $1 AND cat_id = $2);`, imageUID, cat.ID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrImageNotFound
+		}
+		// unset the old cover first, the partial unique index allows a single cover per cat
+		_, err = tx.ExecContext(ctx, `UPDATE cat_images SET is_primary = FALSE WHERE cat_id = $1 AND is_primary;`, cat.ID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE cat_images SET is_primary = TRUE WHERE uid = $1 AND cat_id = $2;`, imageUID, cat.ID)
+		if err != nil {
+			return err
+		}
+		return bumpVersion(ctx, tx, cat.ID, cat.UserID)
+	})
+}
+
+// UpdateImageCaption implements Repository.
+func (d *dbRepository) UpdateImageCaption(ctx context.Context, cat *Cat, imageUID string, caption string) error {
+	return d.db.StartTx(ctx, func(tx *sql.Tx) error {
+		err := lockCat(ctx, tx, cat.ID)
+		if err != nil {
+			return err
+		}
+		updateCaptionQuery := `
+			UPDATE cat_images
+			SET caption = $1
+			WHERE uid = $2 AND cat_id = $3;
+		`
+		res, err := tx.ExecContext(ctx, updateCaptionQuery, caption, imageUID, cat.ID)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrImageNotFound
+		}
+		return bumpVersion(ctx, tx, cat.ID, cat.UserID)
+	})
+}
+
+// DeleteImage implements Repository. The remaining images are renumbered and, if the cover was removed,
+// the first remaining image becomes the cover.
+func (d *dbRepository) DeleteImage(ctx context.Context, cat *Cat, imageUID string) error {
+	return d.db.StartTx(ctx, func(tx *sql.Tx) error {
+		err := lockCat(ctx, tx, cat.ID)
+		if err != nil {
+			return err
+		}
+		images, err := listImages(ctx, tx, cat.ID)
+		if err != nil {
+			return err
+		}
+		idx := slices.IndexFunc(images, func(image CatImage) bool { return image.UID == imageUID })
+		if idx < 0 {
+			return ErrImageNotFound
+		}
+		if len(images) == 1 {
+			return ErrLastImage
+		}
+		_, err = tx.ExecContext(ctx, `DELETE FROM cat_images WHERE id = $1;`, images[idx].ID)
+		if err != nil {
+			return err
+		}
+		images = slices.Delete(images, idx, idx+1)
+		hasCover := slices.ContainsFunc(images, func(image CatImage) bool { return image.IsPrimary })
+		for i, image := range images {
+			primary := image.IsPrimary || (!hasCover && i == 0)
+			if image.Position == i && image.IsPrimary == primary {
+				continue
+			}
+			_, err = tx.ExecContext(ctx, `UPDATE cat_images SET position = $1, is_primary = $2 WHERE id = $3;`, i, primary, image.ID)
+			if err != nil {
+				return err
+			}
+		}
+		return bumpVersion(ctx, tx, cat.ID, cat.UserID)
+	})
+}
+
+// syncImages makes the gallery of a cat match urls. Images whose url is kept keep their uid and caption,
+// the others are removed, and new urls are added. The first image becomes the cover if none is left.
+func syncImages(ctx context.Context, q db.Querier, catID int64, urls []string) error {
+	images, err := listImages(ctx, q, catID)
+	if err != nil {
+		return err
+	}
+	kept := make(map[string][]CatImage)
+	for _, image := range images {
+		kept[image.URL] = append(kept[image.URL], image)
+	}
+	used := make(map[int64]bool, len(urls))
+	hasCover := false
+	for i, url := range urls {
+		if existing := kept[url]; len(existing) > 0 {
+			image := existing[0]
+			kept[url] = existing[1:]
+			used[image.ID] = true
+			hasCover = hasCover || image.IsPrimary
+			if image.Position != i {
+				_, err = q.ExecContext(ctx, `UPDATE cat_images SET position = $1 WHERE id = $2;`, i, image.ID)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		var imageID int64
+		err = q.QueryRowContext(ctx, `INSERT INTO cat_images (uid, cat_id, url, position) VALUES ($1, $2, $3, $4) RETURNING id;`,
+			id.GenerateStringID(16), catID, url, i).Scan(&imageID)
+		if err != nil {
+			return err
+		}
+		used[imageID] = true
+	}
+	for _, image := range images {
+		if used[image.ID] {
+			continue
+		}
+		_, err = q.ExecContext(ctx, `DELETE FROM cat_images WHERE id = $1;`, image.ID)
+		if err != nil {
+			return err
+		}
+	}
+	if hasCover || len(urls) == 0 {
+		return nil
+	}
+	_, err = q.ExecContext(ctx, `UPDATE cat_images SET is_primary = TRUE WHERE cat_id = $1 AND position = 0;`, catID)
+	return err
+}
+
+func listImages(ctx context.Context, q db.Querier, catID int64) ([]CatImage, error) {
+	listImagesQuery := `
+		SELECT ` + catImageColumns + `
+		FROM cat_images
+		WHERE cat_id = $1
+		ORDER BY position ASC;
+	`
+	rows, err := q.QueryContext(ctx, listImagesQuery, catID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make([]CatImage, 0)
+	for rows.Next() {
+		image, err := scanCatImage(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *image)
+	}
+	return res, rows.Err()
+}
+
+func lockCat(ctx context.Context, tx *sql.Tx, catID int64) error {
+	var lockedID int64
+	err := tx.QueryRowContext(ctx, `SELECT id FROM cats WHERE id = $1 FOR UPDATE;`, catID).Scan(&lockedID)
 	if errors.Is(err, sql.ErrNoRows) {
 		return ErrCatNotFound
 	}
 	return err
 }
 
-// Delete implements Repository.
-func (d *dbRepository) Delete(ctx context.Context, uid string, userID int64) error {
-	deleteCatQuery := `
-		DELETE FROM cats
-		WHERE uid = $1 and user_id = $2;
+// bumpVersion records a new version of the cat after its gallery has changed.
+func bumpVersion(ctx context.Context, tx *sql.Tx, catID int64, actorID int64) error {
+	bumpVersionQuery := `
+		UPDATE cats
+		SET version = version + 1
+		WHERE id = $1
+		RETURNING ` + catColumns + `;
 	`
-	row, err := d.db.DB().ExecContext(ctx, deleteCatQuery, uid, userID)
+	cat, err := scanCat(tx.QueryRowContext(ctx, bumpVersionQuery, catID))
 	if err != nil {
 		return err
 	}
-	rowsAffected, err := row.RowsAffected()
+	cat.Images, err = listImages(ctx, tx, catID)
 	if err != nil {
 		return err
 	}
-	if rowsAffected == 0 {
-		return ErrCatNotFound
+	return insertVersion(ctx, tx, cat, cat.Version, VersionUpdate, actorID)
+}
+
+func scanCatImage(row rowScanner) (*CatImage, error) {
+	image := &CatImage{}
+	err := row.Scan(&image.ID, &image.UID, &image.CatID, &image.URL, &image.Position, &image.Caption, &image.IsPrimary, &image.CreatedAt)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return image, nil
+}
+
+// ListTags implements Repository. Lists the curated tags and the ones created by userID.
+func (d *dbRepository) ListTags(ctx context.Context, userID int64) ([]Tag, error) {
+	listTagsQuery := `
+		SELECT id, slug, name, is_curated, created_by_user_id, created_at
+		FROM tags
+		WHERE is_curated OR created_by_user_id = $1
+		ORDER BY is_curated DESC, slug ASC;
+	`
+	rows, err := d.db.DB().QueryContext(ctx, listTagsQuery, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make([]Tag, 0)
+	for rows.Next() {
+		tag := Tag{}
+		var createdBy sql.NullInt64
+		err = rows.Scan(&tag.ID, &tag.Slug, &tag.Name, &tag.IsCurated, &createdBy, &tag.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if createdBy.Valid {
+			tag.CreatedByUserID = &createdBy.Int64
+		}
+		res = append(res, tag)
+	}
+	return res, rows.Err()
+}
+
+// syncTags makes the tags of a cat match slugs. Slugs that are not known yet are created as
+// user-defined tags of userID.
+func syncTags(ctx context.Context, q db.Querier, catID int64, slugs []string, userID int64) error {
+	if slugs == nil {
+		slugs = []string{}
+	}
+	for _, slug := range slugs {
+		createTagQuery := `
+			INSERT INTO tags (
+				slug, name, created_by_user_id
+			) VALUES (
+				$1, $2, $3
+			) ON CONFLICT (slug) DO NOTHING;
+		`
+		_, err := q.ExecContext(ctx, createTagQuery, slug, tagName(slug), userID)
+		if err != nil {
+			return err
+		}
+	}
+	removeTagsQuery := `
+		DELETE FROM cat_tags
+		WHERE cat_id = $1 AND tag_id NOT IN (SELECT id FROM tags WHERE slug = ANY($2));
+	`
+	_, err := q.ExecContext(ctx, removeTagsQuery, catID, pq.Array(slugs))
+	if err != nil {
+		return err
+	}
+	addTagsQuery := `
+		INSERT INTO cat_tags (cat_id, tag_id)
+		SELECT $1, id FROM tags WHERE slug = ANY($2)
+		ON CONFLICT (cat_id, tag_id) DO NOTHING;
+	`
+	_, err = q.ExecContext(ctx, addTagsQuery, catID, pq.Array(slugs))
+	return err
 }
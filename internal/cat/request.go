@@ -4,6 +4,7 @@ import (
 	"errors"
 	"regexp"
 
+	"github.com/citadel-corp/cats-social/internal/common/geo"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 )
 
@@ -19,6 +20,10 @@ type CreateUpdateCatPayload struct {
 	AgeInMonth  int      `json:"ageInMonth"`
 	Description string   `json:"description"`
 	ImageURLS   []string `json:"imageUrls"`
+	Tags        []string `json:"tags"`
+
+	Location   *geo.LocationPayload `json:"location"`
+	Visibility CatVisibility        `json:"visibility"`
 }
 
 func (p CreateUpdateCatPayload) Validate() error {
@@ -34,29 +39,100 @@ func (p CreateUpdateCatPayload) Validate() error {
 		validation.Field(&p.AgeInMonth, validation.Required, validation.Min(1), validation.Max(120082)),
 		validation.Field(&p.Description, validation.Required, validation.Length(1, 200)),
 		validation.Field(&p.ImageURLS, validation.Required, validation.Length(1, 0), validation.Each(validation.Required, validation.NotNil, imgUrlValidationRule)),
+		validation.Field(&p.Tags, validation.Length(0, maxCatTags), validation.Each(validation.Required, tagValidationRule)),
+		validation.Field(&p.Location),
+		validation.Field(&p.Visibility, validation.In(CatVisibilitiesInterface...)),
+	)
+}
+
+type AddCatImagePayload struct {
+	URL     string `json:"url"`
+	Caption string `json:"caption"`
+}
+
+func (p AddCatImagePayload) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.URL, validation.Required, imgUrlValidationRule),
+		validation.Field(&p.Caption, validation.Length(0, 200)),
+	)
+}
+
+type UpdateCatImagePayload struct {
+	Caption string `json:"caption"`
+}
+
+func (p UpdateCatImagePayload) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Caption, validation.Length(0, 200)),
+	)
+}
+
+type ReorderCatImagesPayload struct {
+	ImageIDs []string `json:"imageIds"`
+}
+
+func (p ReorderCatImagesPayload) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.ImageIDs, validation.Required, validation.Each(validation.Required)),
+	)
+}
+
+type ImportCatPayload struct {
+	Format string `schema:"format" binding:"omitempty"`
+	Mode   string `schema:"mode" binding:"omitempty"`
+	DryRun bool   `schema:"dryRun" binding:"omitempty"`
+	Async  bool   `schema:"async" binding:"omitempty"`
+
+	Rows []ImportRow `schema:"-"`
+}
+
+func (p ImportCatPayload) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Mode, validation.In(ImportModeAtomic, ImportModePartial)),
+		validation.Field(&p.Rows, validation.Required),
 	)
 }
 
 type ListCatPayload struct {
-	ID         string `schema:"id" binding:"omitempty"`
-	Limit      int    `schema:"limit" binding:"omitempty"`
-	Offset     int    `schema:"offset" binding:"omitempty"`
-	Race       string `schema:"race" binding:"omitempty"`
-	Sex        string `schema:"sex" binding:"omitempty"`
-	HasMatched string `schema:"hasMatched" binding:"omitempty"`
-	AgeInMonth string `schema:"ageInMonth" binding:"omitempty"`
-	Owned      bool   `schema:"owned" binding:"omitempty"`
-	Search     string `schema:"search" binding:"omitempty"`
-
-	Age            int
-	AgeSearchType  AgeSearchType
-	HasMatchedType HasMatchedSearchType
+	ID         string  `schema:"id" binding:"omitempty"`
+	Limit      int     `schema:"limit" binding:"omitempty"`
+	Offset     int     `schema:"offset" binding:"omitempty"`
+	Race       string  `schema:"race" binding:"omitempty"`
+	Sex        string  `schema:"sex" binding:"omitempty"`
+	HasMatched string  `schema:"hasMatched" binding:"omitempty"`
+	AgeInMonth string  `schema:"ageInMonth" binding:"omitempty"`
+	Owned      bool    `schema:"owned" binding:"omitempty"`
+	Search     string  `schema:"search" binding:"omitempty"`
+	Near       string  `schema:"near" binding:"omitempty"`
+	RadiusKm   float64 `schema:"radiusKm" binding:"omitempty"`
+	Sort       string  `schema:"sort" binding:"omitempty"`
+	Tags       string  `schema:"tags" binding:"omitempty"`
+	TagMatch   string  `schema:"tagMatch" binding:"omitempty"`
+	Facets     string  `schema:"facets" binding:"omitempty"`
+
+	Age            int                  `schema:"-"`
+	AgeSearchType  AgeSearchType        `schema:"-"`
+	HasMatchedType HasMatchedSearchType `schema:"-"`
+	Favorited      bool                 `schema:"-"`
+	Origin         *geo.Point           `schema:"-"`
+	TagSlugs       []string             `schema:"-"`
+	FacetKeys      []string             `schema:"-"`
+	// NotOwned leaves out the viewer's own cats, ExcludeIDs the cats with these ids
+	NotOwned   bool    `schema:"-"`
+	ExcludeIDs []int64 `schema:"-"`
 }
 
 type AgeSearchType int
 type HasMatchedSearchType int
 type OwnedSearchType int
 
+const (
+	SortDistance = "distance"
+
+	ImportModeAtomic  = "atomic"
+	ImportModePartial = "partial"
+)
+
 const (
 	MoreThan AgeSearchType = iota
 	LessThan
@@ -8,13 +8,138 @@ type CreateCatResponse struct {
 }
 
 type CatResponse struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Race        string    `json:"race"`
-	Sex         string    `json:"sex"`
-	AgeInMonth  int       `json:"ageInMonth"`
-	ImageUrls   []string  `json:"imageUrls"`
-	Description string    `json:"description"`
-	HasMatched  bool      `json:"hasMatched"`
-	CreatedAt   time.Time `json:"createdAt"`
+	ID          string             `json:"id"`
+	Name        string             `json:"name"`
+	Race        string             `json:"race"`
+	Sex         string             `json:"sex"`
+	AgeInMonth  int                `json:"ageInMonth"`
+	ImageUrls   []string           `json:"imageUrls"`
+	Images      []CatImageResponse `json:"images,omitempty"`
+	Tags        []string           `json:"tags"`
+	CoverURL    string             `json:"coverUrl,omitempty"`
+	Description string             `json:"description"`
+	HasMatched  bool               `json:"hasMatched"`
+	Visibility  string             `json:"visibility"`
+	Version     int                `json:"version"`
+	CreatedAt   time.Time          `json:"createdAt"`
+
+	IsFavorited   bool `json:"isFavorited"`
+	FavoriteCount int  `json:"favoriteCount"`
+
+	City       string   `json:"city,omitempty"`
+	DistanceKm *float64 `json:"distanceKm,omitempty"`
+}
+
+type ImportCatResponse struct {
+	Mode     string              `json:"mode"`
+	DryRun   bool                `json:"dryRun"`
+	Total    int                 `json:"total"`
+	Valid    int                 `json:"valid"`
+	Imported int                 `json:"imported"`
+	Failed   int                 `json:"failed"`
+	Errors   []ImportRowError    `json:"errors"`
+	Cats     []CreateCatResponse `json:"cats"`
+}
+
+type ImportRowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+type ImportJobResponse struct {
+	ID         string             `json:"id"`
+	Status     string             `json:"status"`
+	Result     *ImportCatResponse `json:"result,omitempty"`
+	Error      string             `json:"error,omitempty"`
+	CreatedAt  time.Time          `json:"createdAt"`
+	FinishedAt *time.Time         `json:"finishedAt,omitempty"`
+}
+
+type ListCatMeta struct {
+	TagCounts []TagCountResponse              `json:"tagCounts"`
+	Facets    map[string][]FacetValueResponse `json:"facets,omitempty"`
+}
+
+type FacetValueResponse struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// makeFacetResponses lists every value of the requested facets, with zero counts for values no cat has.
+func makeFacetResponses(facets []string, counts []FacetCount) map[string][]FacetValueResponse {
+	byFacet := make(map[string]map[string]int, len(facets))
+	for _, fc := range counts {
+		if byFacet[fc.Facet] == nil {
+			byFacet[fc.Facet] = make(map[string]int)
+		}
+		byFacet[fc.Facet][fc.Value] = fc.Count
+	}
+	res := make(map[string][]FacetValueResponse, len(facets))
+	for _, facet := range facets {
+		values := facetValues[facet]
+		res[facet] = make([]FacetValueResponse, len(values))
+		for i, value := range values {
+			res[facet][i] = FacetValueResponse{Value: value, Count: byFacet[facet][value]}
+		}
+	}
+	return res
+}
+
+type TagResponse struct {
+	Slug      string `json:"slug"`
+	Name      string `json:"name"`
+	IsCurated bool   `json:"isCurated"`
+}
+
+type TagCountResponse struct {
+	Slug  string `json:"slug"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func makeTagCountResponses(counts []TagCount) []TagCountResponse {
+	res := make([]TagCountResponse, len(counts))
+	for i, tc := range counts {
+		res[i] = TagCountResponse{Slug: tc.Slug, Name: tc.Name, Count: tc.Count}
+	}
+	return res
+}
+
+type CatImageResponse struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Caption   string    `json:"caption"`
+	Position  int       `json:"position"`
+	IsPrimary bool      `json:"isPrimary"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func makeCatImageResponses(images []CatImage) []CatImageResponse {
+	res := make([]CatImageResponse, len(images))
+	for i, image := range images {
+		res[i] = CatImageResponse{
+			ID:        image.UID,
+			URL:       image.URL,
+			Caption:   image.Caption,
+			Position:  image.Position,
+			IsPrimary: image.IsPrimary,
+			CreatedAt: image.CreatedAt,
+		}
+	}
+	return res
+}
+
+type CatVersionResponse struct {
+	Version   int           `json:"version"`
+	Action    string        `json:"action"`
+	ActorName string        `json:"actorName"`
+	Changes   []FieldChange `json:"changes"`
+	Snapshot  CatSnapshot   `json:"snapshot"`
+	CreatedAt time.Time     `json:"createdAt"`
+}
+
+// CatEventData is published to the owner when one of their cats is created, updated or deleted.
+type CatEventData struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
 }
@@ -2,36 +2,58 @@ package cat
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"regexp"
 	"slices"
 	"strconv"
 	"strings"
 
+	"github.com/citadel-corp/cats-social/internal/common/geo"
 	"github.com/citadel-corp/cats-social/internal/common/id"
+	"github.com/citadel-corp/cats-social/internal/common/job"
 )
 
 var (
 	AgeRegex = regexp.MustCompile("[<>]*\\d+")
 )
 
+// imports with more rows than this run as a background job
+const importAsyncThreshold = 100
+
 type Service interface {
-	List(ctx context.Context, req ListCatPayload, userID int64) ([]CatResponse, error)
+	List(ctx context.Context, req ListCatPayload, userID int64) ([]CatResponse, *ListCatMeta, error)
 	Create(ctx context.Context, req CreateUpdateCatPayload, userID int64) (*CreateCatResponse, error)
 	Update(ctx context.Context, req CreateUpdateCatPayload, id string, userID int64) error
 	Delete(ctx context.Context, id string, userID int64) error
+	History(ctx context.Context, id string, userID int64) ([]CatVersionResponse, error)
+	ListFavorites(ctx context.Context, req ListCatPayload, userID int64) ([]CatResponse, *ListCatMeta, error)
+	ListTags(ctx context.Context, userID int64) ([]TagResponse, error)
+	Favorite(ctx context.Context, id string, userID int64) error
+	Unfavorite(ctx context.Context, id string, userID int64) error
+	Import(ctx context.Context, req ImportCatPayload, userID int64) (*ImportCatResponse, *ImportJobResponse, error)
+	GetImportJob(ctx context.Context, jobID string, userID int64) (*ImportJobResponse, error)
+	ListImages(ctx context.Context, id string, userID int64) ([]CatImageResponse, error)
+	AddImage(ctx context.Context, req AddCatImagePayload, id string, userID int64) (*CatImageResponse, error)
+	ReorderImages(ctx context.Context, req ReorderCatImagesPayload, id string, userID int64) ([]CatImageResponse, error)
+	SetCoverImage(ctx context.Context, id string, imageID string, userID int64) error
+	UpdateImage(ctx context.Context, req UpdateCatImagePayload, id string, imageID string, userID int64) error
+	DeleteImage(ctx context.Context, id string, imageID string, userID int64) error
 }
 
 type userService struct {
 	repository Repository
+	importJobs *job.Registry
 }
 
+// NewService creates the cat Service. Owners are told about changes to their cats from the domain events
+// the repository records, see UserEvents.
 func NewService(repository Repository) Service {
-	return &userService{repository: repository}
+	return &userService{repository: repository, importJobs: job.NewRegistry()}
 }
 
 // List implements Service.
-func (s *userService) List(ctx context.Context, req ListCatPayload, userID int64) ([]CatResponse, error) {
+func (s *userService) List(ctx context.Context, req ListCatPayload, userID int64) ([]CatResponse, *ListCatMeta, error) {
 	// validate payload; if invalid, set to empty so it will be ignored when querying
 	if req.Limit == 0 {
 		req.Limit = 5
@@ -67,9 +89,50 @@ func (s *userService) List(ctx context.Context, req ListCatPayload, userID int64
 	} else if req.HasMatched == "false" {
 		req.HasMatchedType = HasNotMatched
 	}
+	if req.Near != "" {
+		if origin, err := geo.ParsePoint(req.Near); err == nil {
+			req.Origin = &origin
+		}
+	}
+	if req.Origin == nil && (req.RadiusKm > 0 || req.Sort == SortDistance) {
+		// fall back to the caller's own location when no origin is given
+		loc, err := s.repository.GetOwnerLocation(ctx, userID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if loc != nil {
+			req.Origin = &loc.Point
+		}
+	}
+	if req.Tags != "" {
+		req.TagSlugs = normalizeTags(strings.Split(req.Tags, ","))
+	}
+	if req.TagMatch != TagMatchAll {
+		req.TagMatch = TagMatchAny
+	}
+	if req.Facets != "" {
+		for _, facet := range strings.Split(req.Facets, ",") {
+			facet = strings.TrimSpace(facet)
+			if slices.Contains(Facets, facet) && !slices.Contains(req.FacetKeys, facet) {
+				req.FacetKeys = append(req.FacetKeys, facet)
+			}
+		}
+	}
 	cats, err := s.repository.List(ctx, req, userID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	tagCounts, err := s.repository.CountTags(ctx, req, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	meta := &ListCatMeta{TagCounts: makeTagCountResponses(tagCounts)}
+	if len(req.FacetKeys) > 0 {
+		facetCounts, err := s.repository.CountFacets(ctx, req, userID)
+		if err != nil {
+			return nil, nil, err
+		}
+		meta.Facets = makeFacetResponses(req.FacetKeys, facetCounts)
 	}
 	res := make([]CatResponse, len(cats))
 	for i, cat := range cats {
@@ -82,10 +145,29 @@ func (s *userService) List(ctx context.Context, req ListCatPayload, userID int64
 			ImageUrls:   cat.ImageURLS,
 			Description: cat.Description,
 			HasMatched:  cat.HasMatched,
+			Visibility:  string(cat.Visibility),
+			Version:     cat.Version,
 			CreatedAt:   cat.CreatedAt,
+
+			IsFavorited:   cat.IsFavorited,
+			FavoriteCount: cat.FavoriteCount,
+			Images:        makeCatImageResponses(cat.Images),
+			Tags:          cat.Tags,
+		}
+		for _, image := range cat.Images {
+			if image.IsPrimary {
+				res[i].CoverURL = image.URL
+			}
+		}
+		if cat.Location != nil {
+			res[i].City = cat.Location.City
+		}
+		if cat.DistanceKm != nil {
+			distance := geo.ApproximateKm(*cat.DistanceKm)
+			res[i].DistanceKm = &distance
 		}
 	}
-	return res, nil
+	return res, meta, nil
 }
 
 func (s *userService) Create(ctx context.Context, req CreateUpdateCatPayload, userID int64) (*CreateCatResponse, error) {
@@ -93,6 +175,21 @@ func (s *userService) Create(ctx context.Context, req CreateUpdateCatPayload, us
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrValidationFailed, err)
 	}
+	var location *geo.Location
+	if req.Location != nil {
+		loc := req.Location.Location()
+		location = &loc
+	} else {
+		// cats without an explicit location live where their owner does
+		location, err = s.repository.GetOwnerLocation(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	visibility := req.Visibility
+	if visibility == "" {
+		visibility = Public
+	}
 	cat := &Cat{
 		UID:         id.GenerateStringID(16),
 		UserID:      userID,
@@ -103,6 +200,9 @@ func (s *userService) Create(ctx context.Context, req CreateUpdateCatPayload, us
 		Description: req.Description,
 		HasMatched:  false,
 		ImageURLS:   req.ImageURLS,
+		Tags:        normalizeTags(req.Tags),
+		Location:    location,
+		Visibility:  visibility,
 	}
 	cat, err = s.repository.Create(ctx, cat)
 	if err != nil {
@@ -129,6 +229,21 @@ func (s *userService) Update(ctx context.Context, req CreateUpdateCatPayload, ui
 		return ErrCatHasMatched
 	}
 
+	location := cat.Location
+	if req.Location != nil {
+		loc := req.Location.Location()
+		location = &loc
+	}
+	visibility := cat.Visibility
+	if req.Visibility != "" {
+		visibility = req.Visibility
+	}
+	// tags are left alone unless the payload lists them
+	tags := cat.Tags
+	if req.Tags != nil {
+		tags = normalizeTags(req.Tags)
+	}
+
 	cat = &Cat{
 		UID:         uid,
 		UserID:      userID,
@@ -139,12 +254,317 @@ func (s *userService) Update(ctx context.Context, req CreateUpdateCatPayload, ui
 		Description: req.Description,
 		HasMatched:  cat.HasMatched,
 		ImageURLS:   req.ImageURLS,
+		Tags:        tags,
+		Location:    location,
+		Visibility:  visibility,
 	}
-	return s.repository.Update(ctx, cat)
-
+	err = s.repository.Update(ctx, cat)
+	if err != nil {
+		return err
+	}
+	return nil
 }
 
 // Delete implements Service.
 func (s *userService) Delete(ctx context.Context, id string, userID int64) error {
-	return s.repository.Delete(ctx, id, userID)
+	err := s.repository.Delete(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// History implements Service. History of a deleted cat is only shown to users who edited it.
+func (s *userService) History(ctx context.Context, id string, userID int64) ([]CatVersionResponse, error) {
+	versions, err := s.repository.ListVersions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, ErrCatNotFound
+	}
+	if versions[len(versions)-1].Action != VersionDelete {
+		// live cats follow their visibility
+		if _, err = s.repository.GetByUID(ctx, id, userID); err != nil {
+			return nil, err
+		}
+	} else {
+		isActor := slices.ContainsFunc(versions, func(v CatVersion) bool {
+			return v.ActorUserID != nil && *v.ActorUserID == userID
+		})
+		if !isActor {
+			return nil, ErrCatNotFound
+		}
+	}
+
+	res := make([]CatVersionResponse, len(versions))
+	var prev *CatSnapshot
+	for i, v := range versions {
+		res[i] = CatVersionResponse{
+			Version:   v.Version,
+			Action:    string(v.Action),
+			ActorName: v.ActorName,
+			Changes:   diffSnapshots(prev, v.Snapshot),
+			Snapshot:  v.Snapshot,
+			CreatedAt: v.CreatedAt,
+		}
+		prev = &versions[i].Snapshot
+	}
+	return res, nil
+}
+
+// ListFavorites implements Service.
+func (s *userService) ListFavorites(ctx context.Context, req ListCatPayload, userID int64) ([]CatResponse, *ListCatMeta, error) {
+	req.Favorited = true
+	return s.List(ctx, req, userID)
+}
+
+// ListTags implements Service.
+func (s *userService) ListTags(ctx context.Context, userID int64) ([]TagResponse, error) {
+	tags, err := s.repository.ListTags(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]TagResponse, len(tags))
+	for i, tag := range tags {
+		res[i] = TagResponse{
+			Slug:      tag.Slug,
+			Name:      tag.Name,
+			IsCurated: tag.IsCurated,
+		}
+	}
+	return res, nil
+}
+
+// Favorite implements Service.
+func (s *userService) Favorite(ctx context.Context, id string, userID int64) error {
+	cat, err := s.repository.GetByUID(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	return s.repository.AddFavorite(ctx, cat.ID, userID)
+}
+
+// Unfavorite implements Service.
+func (s *userService) Unfavorite(ctx context.Context, id string, userID int64) error {
+	cat, err := s.repository.GetByUID(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	return s.repository.RemoveFavorite(ctx, cat.ID, userID)
+}
+
+// Import implements Service. Small imports run synchronously and return their report,
+// large or explicitly async ones return the background job instead.
+func (s *userService) Import(ctx context.Context, req ImportCatPayload, userID int64) (*ImportCatResponse, *ImportJobResponse, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrValidationFailed, err)
+	}
+	if req.Mode == "" {
+		req.Mode = ImportModeAtomic
+	}
+	if !req.Async && len(req.Rows) <= importAsyncThreshold {
+		res, err := s.runImport(ctx, req, userID)
+		return res, nil, err
+	}
+	j := s.importJobs.Start(userID, func(ctx context.Context) (any, error) {
+		return s.runImport(ctx, req, userID)
+	})
+	return nil, makeImportJobResponse(j), nil
+}
+
+// GetImportJob implements Service.
+func (s *userService) GetImportJob(ctx context.Context, jobID string, userID int64) (*ImportJobResponse, error) {
+	j, err := s.importJobs.Get(jobID, userID)
+	if errors.Is(err, job.ErrJobNotFound) {
+		return nil, ErrImportJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return makeImportJobResponse(j), nil
+}
+
+func (s *userService) runImport(ctx context.Context, req ImportCatPayload, userID int64) (*ImportCatResponse, error) {
+	res := &ImportCatResponse{
+		Mode:   req.Mode,
+		DryRun: req.DryRun,
+		Total:  len(req.Rows),
+		Errors: make([]ImportRowError, 0),
+		Cats:   make([]CreateCatResponse, 0),
+	}
+	ownerLocation, err := s.repository.GetOwnerLocation(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	cats := make([]*Cat, 0, len(req.Rows))
+	lines := make([]int, 0, len(req.Rows))
+	for _, row := range req.Rows {
+		if row.Err != nil {
+			res.Errors = append(res.Errors, ImportRowError{Line: row.Line, Error: row.Err.Error()})
+			continue
+		}
+		if err := row.Payload.Validate(); err != nil {
+			res.Errors = append(res.Errors, ImportRowError{Line: row.Line, Error: err.Error()})
+			continue
+		}
+		location := ownerLocation
+		if row.Payload.Location != nil {
+			loc := row.Payload.Location.Location()
+			location = &loc
+		}
+		visibility := row.Payload.Visibility
+		if visibility == "" {
+			visibility = Public
+		}
+		cats = append(cats, &Cat{
+			UID:         id.GenerateStringID(16),
+			UserID:      userID,
+			Name:        row.Payload.Name,
+			Race:        row.Payload.Race,
+			Sex:         CatSex(strings.ToLower(string(row.Payload.Sex))),
+			Age:         row.Payload.AgeInMonth,
+			Description: row.Payload.Description,
+			HasMatched:  false,
+			ImageURLS:   row.Payload.ImageURLS,
+			Tags:        normalizeTags(row.Payload.Tags),
+			Location:    location,
+			Visibility:  visibility,
+		})
+		lines = append(lines, row.Line)
+	}
+	res.Valid = len(cats)
+	res.Failed = len(res.Errors)
+	if req.DryRun {
+		return res, nil
+	}
+
+	switch req.Mode {
+	case ImportModeAtomic:
+		if res.Failed > 0 {
+			return res, ErrImportRowsInvalid
+		}
+		created, err := s.repository.CreateMany(ctx, cats)
+		if err != nil {
+			return nil, err
+		}
+		for _, cat := range created {
+			res.Cats = append(res.Cats, CreateCatResponse{Id: cat.UID, CreatedAt: cat.CreatedAt})
+		}
+	case ImportModePartial:
+		for i, cat := range cats {
+			created, err := s.repository.Create(ctx, cat)
+			if err != nil {
+				res.Errors = append(res.Errors, ImportRowError{Line: lines[i], Error: err.Error()})
+				continue
+			}
+			res.Cats = append(res.Cats, CreateCatResponse{Id: created.UID, CreatedAt: created.CreatedAt})
+		}
+		res.Failed = len(res.Errors)
+	}
+	res.Imported = len(res.Cats)
+	return res, nil
+}
+
+func makeImportJobResponse(j job.Job) *ImportJobResponse {
+	res := &ImportJobResponse{
+		ID:         j.ID,
+		Status:     string(j.Status),
+		Error:      j.Error,
+		CreatedAt:  j.CreatedAt,
+		FinishedAt: j.FinishedAt,
+	}
+	if result, ok := j.Result.(*ImportCatResponse); ok {
+		res.Result = result
+	}
+	return res
+}
+
+// ListImages implements Service.
+func (s *userService) ListImages(ctx context.Context, id string, userID int64) ([]CatImageResponse, error) {
+	cat, err := s.repository.GetByUID(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	images, err := s.repository.ListImages(ctx, cat.ID)
+	if err != nil {
+		return nil, err
+	}
+	return makeCatImageResponses(images), nil
+}
+
+// AddImage implements Service.
+func (s *userService) AddImage(ctx context.Context, req AddCatImagePayload, uid string, userID int64) (*CatImageResponse, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrValidationFailed, err)
+	}
+	cat, err := s.repository.GetByUIDAndUserID(ctx, uid, userID)
+	if err != nil {
+		return nil, err
+	}
+	image := &CatImage{
+		UID:     id.GenerateStringID(16),
+		URL:     req.URL,
+		Caption: req.Caption,
+	}
+	err = s.repository.AddImage(ctx, cat, image)
+	if err != nil {
+		return nil, err
+	}
+	return &makeCatImageResponses([]CatImage{*image})[0], nil
+}
+
+// ReorderImages implements Service.
+func (s *userService) ReorderImages(ctx context.Context, req ReorderCatImagesPayload, id string, userID int64) ([]CatImageResponse, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrValidationFailed, err)
+	}
+	cat, err := s.repository.GetByUIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.repository.ReorderImages(ctx, cat, req.ImageIDs)
+	if err != nil {
+		return nil, err
+	}
+	images, err := s.repository.ListImages(ctx, cat.ID)
+	if err != nil {
+		return nil, err
+	}
+	return makeCatImageResponses(images), nil
+}
+
+// SetCoverImage implements Service.
+func (s *userService) SetCoverImage(ctx context.Context, id string, imageID string, userID int64) error {
+	cat, err := s.repository.GetByUIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	return s.repository.SetCoverImage(ctx, cat, imageID)
+}
+
+// UpdateImage implements Service.
+func (s *userService) UpdateImage(ctx context.Context, req UpdateCatImagePayload, id string, imageID string, userID int64) error {
+	err := req.Validate()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrValidationFailed, err)
+	}
+	cat, err := s.repository.GetByUIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	return s.repository.UpdateImageCaption(ctx, cat, imageID, req.Caption)
+}
+
+// DeleteImage implements Service.
+func (s *userService) DeleteImage(ctx context.Context, id string, imageID string, userID int64) error {
+	cat, err := s.repository.GetByUIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	return s.repository.DeleteImage(ctx, cat, imageID)
 }
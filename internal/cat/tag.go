@@ -0,0 +1,81 @@
+package cat
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+
+	maxCatTags   = 10
+	minTagLength = 2
+	maxTagLength = 30
+)
+
+// Tag is either one of the curated tags seeded by migration or one created by a user while tagging a cat.
+type Tag struct {
+	ID              int64
+	Slug            string
+	Name            string
+	IsCurated       bool
+	CreatedByUserID *int64
+	CreatedAt       time.Time
+}
+
+type TagCount struct {
+	Slug  string
+	Name  string
+	Count int
+}
+
+var tagValidationRule = validation.NewStringRule(func(s string) bool {
+	slug := NormalizeTag(s)
+	return len(slug) >= minTagLength && len(slug) <= maxTagLength
+}, "tag must have between 2 and 30 letters or digits")
+
+// NormalizeTag turns user input like "Good with kids" into the slug "good-with-kids".
+// Characters other than ascii letters and digits are dropped.
+func NormalizeTag(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+		case r == ' ', r == '-', r == '_':
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// normalizeTags returns the distinct slugs of tags in sorted order, the order tags are stored in.
+func normalizeTags(tags []string) []string {
+	res := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		slug := NormalizeTag(tag)
+		if slug == "" || seen[slug] {
+			continue
+		}
+		seen[slug] = true
+		res = append(res, slug)
+	}
+	slices.Sort(res)
+	return res
+}
+
+// tagName is the display name given to user-defined tags, "good-with-kids" becomes "Good with kids".
+func tagName(slug string) string {
+	name := strings.ReplaceAll(slug, "-", " ")
+	return strings.ToUpper(name[:1]) + name[1:]
+}
@@ -0,0 +1,20 @@
+package catdiscovery
+
+import "time"
+
+type Decision string
+
+const (
+	Pass Decision = "pass"
+	Like Decision = "like"
+)
+
+// CatDecision is what the owner of a cat decided about a candidate shown to it.
+type CatDecision struct {
+	ID             int64
+	CatID          int64
+	CandidateCatID int64
+	UserID         int64
+	Decision       Decision
+	CreatedAt      time.Time
+}
@@ -0,0 +1,7 @@
+package catdiscovery
+
+import "errors"
+
+var (
+	ErrValidationFailed = errors.New("validation failed")
+)
@@ -0,0 +1,146 @@
+package catdiscovery
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/citadel-corp/cats-social/internal/cat"
+	catmatch "github.com/citadel-corp/cats-social/internal/cat_match"
+	"github.com/citadel-corp/cats-social/internal/common/middleware"
+	"github.com/citadel-corp/cats-social/internal/common/request"
+	"github.com/citadel-corp/cats-social/internal/common/response"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/schema"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) Discover(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{})
+		return
+	}
+
+	newSchema := schema.NewDecoder()
+	newSchema.IgnoreUnknownKeys(true)
+
+	var req DiscoverPayload
+	if err = newSchema.Decode(&req, r.URL.Query()); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
+			Message: "Bad request",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	params := mux.Vars(r)
+	id := params["id"]
+	candidates, err := h.service.Discover(r.Context(), req, id, userID)
+	if errors.Is(err, ErrValidationFailed) || errors.Is(err, cat.ErrCatHasMatched) {
+		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
+			Message: "Bad request",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, cat.ErrCatNotFound) {
+		response.JSON(w, http.StatusNotFound, response.ResponseBody{
+			Message: "Not found",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
+			Message: "Internal server error",
+			Error:   err.Error(),
+		})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.ResponseBody{
+		Message: "success",
+		Data:    candidates,
+	})
+}
+
+func (h *Handler) Decide(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{})
+		return
+	}
+
+	var req DecidePayload
+
+	err = request.DecodeJSON(w, r, &req)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
+			Message: "Failed to decode JSON",
+			Error:   err.Error(),
+		})
+		return
+	}
+	params := mux.Vars(r)
+	id := params["id"]
+	candidateID := params["candidateId"]
+	decision, err := h.service.Decide(r.Context(), req, id, candidateID, userID)
+	if errors.Is(err, ErrValidationFailed) || errors.Is(err, catmatch.ErrCatSameUser) || errors.Is(err, catmatch.ErrCatSameSex) ||
+		errors.Is(err, catmatch.ErrCatHasMatched) {
+		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
+			Message: "Bad request",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, cat.ErrCatNotFound) {
+		response.JSON(w, http.StatusNotFound, response.ResponseBody{
+			Message: "Not found",
+			Error:   err.Error(),
+		})
+		return
+	}
+	// a mutual like approves the pending request, which can lose a race with its reject, withdraw or expiry
+	if errors.Is(err, catmatch.ErrCatMatchNoLongerValid) {
+		response.JSON(w, http.StatusConflict, response.ResponseBody{
+			Message: "Conflict",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, catmatch.ErrCatMatchForbidden) {
+		response.JSON(w, http.StatusForbidden, response.ResponseBody{
+			Message: "Forbidden",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
+			Message: "Internal server error",
+			Error:   err.Error(),
+		})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.ResponseBody{
+		Message: "success",
+		Data:    decision,
+	})
+}
+
+func getUserID(r *http.Request) (int64, error) {
+	if authValue, ok := r.Context().Value(middleware.ContextAuthKey{}).(string); ok {
+		return strconv.ParseInt(authValue, 10, 64)
+	} else {
+		slog.Error("cannot parse auth value from context")
+		return 0, errors.New("cannot parse auth value from context")
+	}
+}
@@ -0,0 +1,81 @@
+package catdiscovery
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/citadel-corp/cats-social/internal/common/db"
+)
+
+type Repository interface {
+	Save(ctx context.Context, decision *CatDecision) error
+	Get(ctx context.Context, catID int64, candidateCatID int64) (*CatDecision, error)
+	ListDecidedCatIDs(ctx context.Context, catID int64) ([]int64, error)
+}
+
+type dbRepository struct {
+	db *db.DB
+}
+
+func NewRepository(db *db.DB) Repository {
+	return &dbRepository{db: db}
+}
+
+// Save implements Repository. Deciding again about the same candidate replaces the earlier decision.
+func (d *dbRepository) Save(ctx context.Context, decision *CatDecision) error {
+	saveQuery := `
+		INSERT INTO cat_decisions (
+			cat_id, candidate_cat_id, user_id, decision
+		) VALUES (
+			$1, $2, $3, $4
+		)
+		ON CONFLICT (cat_id, candidate_cat_id) DO UPDATE
+		SET user_id = EXCLUDED.user_id, decision = EXCLUDED.decision, created_at = current_timestamp
+		RETURNING id, created_at;
+	`
+	row := d.db.DB().QueryRowContext(ctx, saveQuery, decision.CatID, decision.CandidateCatID, decision.UserID, decision.Decision)
+	return row.Scan(&decision.ID, &decision.CreatedAt)
+}
+
+// Get implements Repository. Returns nil when the cat's owner hasn't decided about the candidate.
+func (d *dbRepository) Get(ctx context.Context, catID int64, candidateCatID int64) (*CatDecision, error) {
+	getQuery := `
+		SELECT id, cat_id, candidate_cat_id, user_id, decision, created_at
+		FROM cat_decisions
+		WHERE cat_id = $1 AND candidate_cat_id = $2;
+	`
+	decision := &CatDecision{}
+	err := d.db.DB().QueryRowContext(ctx, getQuery, catID, candidateCatID).Scan(&decision.ID, &decision.CatID,
+		&decision.CandidateCatID, &decision.UserID, &decision.Decision, &decision.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decision, nil
+}
+
+// ListDecidedCatIDs implements Repository.
+func (d *dbRepository) ListDecidedCatIDs(ctx context.Context, catID int64) ([]int64, error) {
+	listQuery := `
+		SELECT candidate_cat_id
+		FROM cat_decisions
+		WHERE cat_id = $1;
+	`
+	rows, err := d.db.DB().QueryContext(ctx, listQuery, catID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		res = append(res, id)
+	}
+	return res, rows.Err()
+}
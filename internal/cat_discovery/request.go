@@ -0,0 +1,25 @@
+package catdiscovery
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+type DiscoverPayload struct {
+	Limit int `schema:"limit" binding:"omitempty"`
+}
+
+func (p DiscoverPayload) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Limit, validation.Min(0), validation.Max(50)),
+	)
+}
+
+type DecidePayload struct {
+	Decision Decision `json:"decision"`
+}
+
+func (p DecidePayload) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Decision, validation.Required, validation.In(Pass, Like)),
+	)
+}
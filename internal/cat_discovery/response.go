@@ -0,0 +1,12 @@
+package catdiscovery
+
+import catmatch "github.com/citadel-corp/cats-social/internal/cat_match"
+
+type DecisionResponse struct {
+	CandidateID string `json:"candidateId"`
+	Decision    string `json:"decision"`
+	// Mutual is set when the candidate's owner liked the cat too
+	Mutual bool `json:"mutual"`
+	// Match is the request created for a mutual like, missing when one between the cats already existed
+	Match *catmatch.CreateCatMatchResponse `json:"match,omitempty"`
+}
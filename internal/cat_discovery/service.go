@@ -0,0 +1,127 @@
+package catdiscovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/citadel-corp/cats-social/internal/cat"
+	catmatch "github.com/citadel-corp/cats-social/internal/cat_match"
+	catrecommendation "github.com/citadel-corp/cats-social/internal/cat_recommendation"
+)
+
+const (
+	defaultBatchSize = 10
+	// mutualLikeMessage is the message of match requests created for mutual likes
+	mutualLikeMessage = "You both liked each other's cats!"
+)
+
+type Service interface {
+	Discover(ctx context.Context, req DiscoverPayload, catID string, userID int64) ([]catrecommendation.RecommendationResponse, error)
+	Decide(ctx context.Context, req DecidePayload, catID string, candidateID string, userID int64) (*DecisionResponse, error)
+}
+
+type catDiscoveryService struct {
+	repository            Repository
+	catRepository         cat.Repository
+	recommendationService catrecommendation.Service
+	catMatchService       catmatch.Service
+}
+
+func NewService(repository Repository, catRepository cat.Repository, recommendationService catrecommendation.Service, catMatchService catmatch.Service) Service {
+	return &catDiscoveryService{
+		repository:            repository,
+		catRepository:         catRepository,
+		recommendationService: recommendationService,
+		catMatchService:       catMatchService,
+	}
+}
+
+// Discover implements Service. The batch holds the best recommendations the user hasn't decided about
+// for this cat yet.
+func (s *catDiscoveryService) Discover(ctx context.Context, req DiscoverPayload, catID string, userID int64) ([]catrecommendation.RecommendationResponse, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrValidationFailed, err)
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultBatchSize
+	}
+	target, err := s.catRepository.GetByUIDAndUserID(ctx, catID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if target.HasMatched {
+		return nil, cat.ErrCatHasMatched
+	}
+	decided, err := s.repository.ListDecidedCatIDs(ctx, target.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.recommendationService.Rank(ctx, target, userID, decided, req.Limit)
+}
+
+// Decide implements Service. Liking a candidate whose owner already liked the cat back creates a match
+// request from the cat to the candidate.
+func (s *catDiscoveryService) Decide(ctx context.Context, req DecidePayload, catID string, candidateID string, userID int64) (*DecisionResponse, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrValidationFailed, err)
+	}
+	target, err := s.catRepository.GetByUIDAndUserID(ctx, catID, userID)
+	if err != nil {
+		return nil, err
+	}
+	// private cats can't be discovered
+	candidate, err := s.catRepository.GetByUID(ctx, candidateID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if candidate.UserID == userID {
+		return nil, catmatch.ErrCatSameUser
+	}
+	if candidate.Sex == target.Sex {
+		return nil, catmatch.ErrCatSameSex
+	}
+
+	err = s.repository.Save(ctx, &CatDecision{
+		CatID:          target.ID,
+		CandidateCatID: candidate.ID,
+		UserID:         userID,
+		Decision:       req.Decision,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := &DecisionResponse{CandidateID: candidate.UID, Decision: string(req.Decision)}
+	if req.Decision != Like {
+		return res, nil
+	}
+
+	other, err := s.repository.Get(ctx, candidate.ID, target.ID)
+	if err != nil {
+		return nil, err
+	}
+	if other == nil || other.Decision != Like {
+		return res, nil
+	}
+	res.Mutual = true
+	match, err := s.catMatchService.Create(ctx, catmatch.PostCatMatch{
+		MatchCatId: candidate.UID,
+		UserCatId:  target.UID,
+		Message:    mutualLikeMessage,
+	}, userID)
+	if errors.Is(err, catmatch.ErrMatchAlreadyRequested) || errors.Is(err, catmatch.ErrReciprocalMatch) {
+		// the owners found each other before, the pending request stands
+		return res, nil
+	}
+	if errors.Is(err, catmatch.ErrMatchCooldown) {
+		// the cats dissolved a match recently, the like is kept but they can't be matched yet
+		return res, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	res.Match = match
+	return res, nil
+}
@@ -0,0 +1,200 @@
+package catmatch_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/citadel-corp/cats-social/internal/cat"
+	catmatch "github.com/citadel-corp/cats-social/internal/cat_match"
+	"github.com/citadel-corp/cats-social/internal/common/db"
+	"github.com/citadel-corp/cats-social/internal/common/id"
+	"github.com/citadel-corp/cats-social/internal/user"
+	"github.com/lib/pq"
+)
+
+const (
+	raceRounds  = 10
+	raceMatches = 20
+)
+
+// TestConcurrentApprovals checks that concurrent match approvals can never put a cat in two active
+// matches. Every round creates a hub cat with many pending matches, approves all of them at once and
+// checks that exactly one approval went through and every other one was refused cleanly. The hub is the
+// requested cat on even rounds and the requesting cat on odd ones, so both sides of a match are covered.
+// It runs against the database configured by the same DB_* variables as the server, and is skipped
+// without one.
+func TestConcurrentApprovals(t *testing.T) {
+	if os.Getenv("DB_HOST") == "" {
+		t.Skip("DB_HOST is not set")
+	}
+	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?%s",
+		os.Getenv("DB_USERNAME"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_NAME"), os.Getenv("DB_PARAMS"))
+	database, err := db.Connect(connStr)
+	if err != nil {
+		t.Fatalf("connect to database: %v", err)
+	}
+
+	catRepository := cat.NewRepository(database)
+	h := &harness{
+		db:              database,
+		userRepository:  user.NewRepository(database),
+		catRepository:   catRepository,
+		catMatchService: catmatch.NewService(catmatch.NewRepository(database), catRepository, catmatch.Config{}),
+	}
+	ctx := context.Background()
+	t.Cleanup(func() {
+		if err := h.cleanup(ctx); err != nil {
+			t.Errorf("cleanup: %v", err)
+		}
+	})
+	for round := 0; round < raceRounds; round++ {
+		err = h.run(ctx, round, raceMatches)
+		if err != nil {
+			t.Errorf("round %d: %v", round, err)
+		}
+	}
+}
+
+type harness struct {
+	db              *db.DB
+	userRepository  user.Repository
+	catRepository   cat.Repository
+	catMatchService catmatch.Service
+	userIDs         []int64
+}
+
+// run approves n matches of a single hub cat concurrently and checks that only one of them succeeded.
+func (h *harness) run(ctx context.Context, round int, n int) error {
+	hubIsReceiver := round%2 == 0
+	hubOwner, err := h.createUser(ctx)
+	if err != nil {
+		return err
+	}
+	hub, err := h.createCat(ctx, hubOwner, cat.Female)
+	if err != nil {
+		return err
+	}
+
+	type approval struct {
+		matchID  string
+		approver int64
+	}
+	approvals := make([]approval, 0, n)
+	for i := 0; i < n; i++ {
+		other, err := h.createUser(ctx)
+		if err != nil {
+			return err
+		}
+		otherCat, err := h.createCat(ctx, other, cat.Male)
+		if err != nil {
+			return err
+		}
+		issuer, issuerCat, receiver, receiverCat := other, otherCat, hubOwner, hub
+		if !hubIsReceiver {
+			issuer, issuerCat, receiver, receiverCat = hubOwner, hub, other, otherCat
+		}
+		match, err := h.catMatchService.Create(ctx, catmatch.PostCatMatch{
+			MatchCatId: receiverCat.UID,
+			UserCatId:  issuerCat.UID,
+			Message:    fmt.Sprintf("round %d request %d", round, i),
+		}, issuer)
+		if err != nil {
+			return fmt.Errorf("create match: %w", err)
+		}
+		approvals = append(approvals, approval{matchID: match.ID, approver: receiver})
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	var unexpected []error
+	start := make(chan struct{})
+	for _, a := range approvals {
+		wg.Add(1)
+		go func(a approval) {
+			defer wg.Done()
+			<-start
+			err := h.catMatchService.Approve(ctx, catmatch.ApproveOrRejectMatch{MatchUID: a.matchID}, a.approver)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				succeeded += 1
+			case errors.Is(err, catmatch.ErrCatHasMatched), errors.Is(err, catmatch.ErrCatMatchNoLongerValid):
+			default:
+				// deadlocks and other database errors would reach users as a 500
+				unexpected = append(unexpected, fmt.Errorf("approve %s: %w", a.matchID, err))
+			}
+		}(a)
+	}
+	close(start)
+	wg.Wait()
+	if len(unexpected) > 0 {
+		return errors.Join(unexpected...)
+	}
+
+	var active int
+	countQuery := `
+		SELECT COUNT(*)
+		FROM cat_pairings
+		WHERE cat_id = $1 AND ended_at IS NULL;
+	`
+	err = h.db.DB().QueryRowContext(ctx, countQuery, hub.ID).Scan(&active)
+	if err != nil {
+		return err
+	}
+	if succeeded != 1 || active != 1 {
+		return fmt.Errorf("hub cat %s has %d active matches after %d successful approvals", hub.UID, active, succeeded)
+	}
+	return nil
+}
+
+func (h *harness) createUser(ctx context.Context) (int64, error) {
+	uid := id.GenerateStringID(16)
+	u, err := h.userRepository.Create(ctx, &user.User{
+		UID:            uid,
+		Email:          fmt.Sprintf("matchrace-%s@example.com", uid),
+		Name:           "matchrace",
+		HashedPassword: "-",
+	})
+	if err != nil {
+		return 0, fmt.Errorf("create user: %w", err)
+	}
+	h.userIDs = append(h.userIDs, u.ID)
+	return u.ID, nil
+}
+
+func (h *harness) createCat(ctx context.Context, userID int64, sex cat.CatSex) (*cat.Cat, error) {
+	c, err := h.catRepository.Create(ctx, &cat.Cat{
+		UID:         id.GenerateStringID(16),
+		UserID:      userID,
+		Name:        "matchrace",
+		Race:        cat.Persian,
+		Sex:         sex,
+		Age:         12,
+		Description: "created by matchrace",
+		ImageURLS:   []string{"https://example.com/cat.jpg"},
+		Visibility:  cat.Public,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create cat: %w", err)
+	}
+	return c, nil
+}
+
+// cleanup removes every user created by the harness along with their cats, matches and cat history.
+func (h *harness) cleanup(ctx context.Context) error {
+	if len(h.userIDs) == 0 {
+		return nil
+	}
+	_, err := h.db.DB().ExecContext(ctx, `DELETE FROM cat_versions WHERE cat_uid IN (SELECT uid FROM cats WHERE user_id = ANY($1));`, pq.Array(h.userIDs))
+	if err != nil {
+		return err
+	}
+	_, err = h.db.DB().ExecContext(ctx, `DELETE FROM users WHERE id = ANY($1);`, pq.Array(h.userIDs))
+	return err
+}
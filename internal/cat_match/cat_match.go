@@ -5,9 +5,18 @@ import "time"
 type MatchStatus string
 
 const (
-	Pending  MatchStatus = "pending"
-	Approved MatchStatus = "approved"
-	Rejected MatchStatus = "rejected"
+	Pending   MatchStatus = "pending"
+	Approved  MatchStatus = "approved"
+	Rejected  MatchStatus = "rejected"
+	Cancelled MatchStatus = "cancelled"
+	Withdrawn MatchStatus = "withdrawn"
+	Expired   MatchStatus = "expired"
+	// Dissolved matches were approved and later ended by one of the owners
+	Dissolved MatchStatus = "dissolved"
+
+	// incoming matches were requested by someone else for one of the user's cats, outgoing ones by the user
+	Incoming = "incoming"
+	Outgoing = "outgoing"
 )
 
 type CatMatches struct {
@@ -20,4 +29,35 @@ type CatMatches struct {
 	Message        string      `json:"message"`
 	ApprovalStatus MatchStatus `json:"approval_status"`
 	CreatedAt      *time.Time  `json:"created_at"`
+	// Age is how long ago the match was requested, measured by the database clock created_at was set with
+	Age time.Duration `json:"-"`
+
+	// versions of both cats at the time the match was requested
+	IssuerCatVersion int `json:"issuer_cat_version"`
+	MatchCatVersion  int `json:"match_cat_version"`
+
+	ReceiverViewedAt *time.Time `json:"receiver_viewed_at"`
+
+	DissolveReason string `json:"dissolve_reason"`
+}
+
+// CatMatchCounts are the totals of a user's matches for the current list filters.
+type CatMatchCounts struct {
+	Incoming       int
+	Outgoing       int
+	UnreadIncoming int
+}
+
+// CatMatchHistory is a flattened match row used when exporting a user's history.
+type CatMatchHistory struct {
+	UID            string
+	IssuerUserId   int64
+	IssuerCatUID   string
+	IssuerCatName  string
+	MatchUserId    int64
+	MatchCatUID    string
+	MatchCatName   string
+	Message        string
+	ApprovalStatus MatchStatus
+	CreatedAt      time.Time
 }
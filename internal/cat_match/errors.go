@@ -1,12 +1,22 @@
 package catmatch
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/citadel-corp/cats-social/internal/common/cursor"
+)
 
 var (
 	ErrCatMatchNotFound      = errors.New("cat match not found")
 	ErrCatMatchNoLongerValid = errors.New("cat match no longer valid")
-	ErrCatHasMatched         = errors.New("cat has matched before")
+	ErrCatMatchForbidden     = errors.New("user is not allowed to change this cat match")
+	ErrCatHasMatched         = errors.New("cat is in an active match")
 	ErrCatSameSex            = errors.New("cat has same sex")
 	ErrCatSameUser           = errors.New("cat has same user")
+	ErrCatVersionChanged     = errors.New("cat has changed since it was viewed")
 	ErrValidationFailed      = errors.New("validation failed")
+	ErrInvalidCursor         = cursor.ErrInvalidCursor
+	ErrMatchAlreadyRequested = errors.New("a match between these cats is already pending")
+	ErrReciprocalMatch       = errors.New("the other cat already requested a match with yours, approve it instead")
+	ErrMatchCooldown         = errors.New("these cats dissolved a match recently")
 )
@@ -0,0 +1,84 @@
+package catmatch
+
+import (
+	"context"
+
+	"github.com/citadel-corp/cats-social/internal/common/db"
+	"github.com/citadel-corp/cats-social/internal/event"
+	"github.com/citadel-corp/cats-social/internal/outbox"
+)
+
+// eventTypes is the event the participants get when a match enters a status.
+var eventTypes = map[MatchStatus]event.Type{
+	Pending:   event.MatchReceived,
+	Approved:  event.MatchApproved,
+	Rejected:  event.MatchRejected,
+	Withdrawn: event.MatchWithdrawn,
+	Expired:   event.MatchExpired,
+	Cancelled: event.MatchCancelled,
+	Dissolved: event.MatchDissolved,
+}
+
+// OutboxAggregate is the aggregate type of match domain events, identified by the match UID.
+const OutboxAggregate = "match"
+
+// outboxTypes is the domain event recorded when a match enters a status.
+var outboxTypes = map[MatchStatus]outbox.Type{
+	Pending:   outbox.MatchRequested,
+	Approved:  outbox.MatchApproved,
+	Rejected:  outbox.MatchRejected,
+	Withdrawn: outbox.MatchWithdrawn,
+	Expired:   outbox.MatchExpired,
+	Cancelled: outbox.MatchCancelled,
+	Dissolved: outbox.MatchDissolved,
+}
+
+// OutboxEvent is the payload of the match domain events.
+type OutboxEvent struct {
+	ID             string      `json:"id"`
+	Status         MatchStatus `json:"status"`
+	IssuerCatID    int64       `json:"issuerCatId"`
+	IssuerUserID   int64       `json:"issuerUserId"`
+	ReceiverCatID  int64       `json:"receiverCatId"`
+	ReceiverUserID int64       `json:"receiverUserId"`
+	// ActorUserID made the change, zero for changes made by the service itself
+	ActorUserID int64 `json:"actorUserId"`
+	// Reason is set on dissolved matches
+	Reason string `json:"reason,omitempty"`
+}
+
+// RecordEvent writes the domain event of match entering status in the transaction changing it.
+func RecordEvent(ctx context.Context, q db.Querier, match CatMatches, status MatchStatus, actorID int64) error {
+	return outbox.Record(ctx, q, OutboxAggregate, match.UID, outboxTypes[status], OutboxEvent{
+		ID:             match.UID,
+		Status:         status,
+		IssuerCatID:    match.IssuerCatId,
+		IssuerUserID:   match.IssueUserId,
+		ReceiverCatID:  match.MatchCatId,
+		ReceiverUserID: match.MatchUserId,
+		ActorUserID:    actorID,
+		Reason:         match.DissolveReason,
+	})
+}
+
+// UserEvents turns a match domain event into the events its participants are told about, the same ones
+// notify publishes.
+func UserEvents(e outbox.Event) ([]event.Event, error) {
+	var payload OutboxEvent
+	err := e.Decode(&payload)
+	if err != nil {
+		return nil, err
+	}
+	typ, ok := eventTypes[payload.Status]
+	if !ok {
+		return nil, nil
+	}
+	data := MatchEventData{ID: payload.ID, Status: string(payload.Status), Reason: payload.Reason}
+	res := make([]event.Event, 0, 2)
+	for _, userID := range []int64{payload.IssuerUserID, payload.ReceiverUserID} {
+		if userID != payload.ActorUserID {
+			res = append(res, event.Event{Type: typ, UserID: userID, Data: data})
+		}
+	}
+	return res, nil
+}
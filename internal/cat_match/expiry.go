@@ -0,0 +1,106 @@
+package catmatch
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/citadel-corp/cats-social/internal/event"
+)
+
+const defaultExpiryInterval = time.Minute
+
+// Reminder is told about pending matches that are about to expire.
+type Reminder interface {
+	RemindPending(ctx context.Context, match CatMatches, expiresAt time.Time) error
+}
+
+// logReminder only logs reminders, used until a real channel is configured.
+type logReminder struct{}
+
+func (logReminder) RemindPending(ctx context.Context, match CatMatches, expiresAt time.Time) error {
+	slog.Info(fmt.Sprintf("cat match %s expires at %s", match.UID, expiresAt.Format(time.RFC3339)))
+	return nil
+}
+
+// publishReminder publishes reminders as events to the receiver of the match, who has to answer it.
+type publishReminder struct {
+	publisher event.Publisher
+}
+
+// NewPublishReminder creates a Reminder publishing a match.expiring event through publisher.
+func NewPublishReminder(publisher event.Publisher) Reminder {
+	return publishReminder{publisher: publisher}
+}
+
+func (r publishReminder) RemindPending(ctx context.Context, match CatMatches, expiresAt time.Time) error {
+	r.publisher.Publish(ctx, match.MatchUserId, event.MatchExpiring, MatchEventData{
+		ID:        match.UID,
+		Status:    string(match.ApprovalStatus),
+		ExpiresAt: &expiresAt,
+	})
+	return nil
+}
+
+// Expirer periodically expires pending matches older than Config.TTL and sends reminders
+// Config.ReminderBefore the expiry.
+type Expirer struct {
+	repository Repository
+	config     Config
+	reminder   Reminder
+}
+
+// NewExpirer creates an Expirer. A nil reminder logs reminders instead of sending them. Expiries reach
+// the participants like every other status change, through the domain events.
+func NewExpirer(repository Repository, config Config, reminder Reminder) *Expirer {
+	if reminder == nil {
+		reminder = logReminder{}
+	}
+	if config.ExpiryInterval <= 0 {
+		config.ExpiryInterval = defaultExpiryInterval
+	}
+	return &Expirer{repository: repository, config: config, reminder: reminder}
+}
+
+// Run sweeps pending matches every Config.ExpiryInterval until ctx is done. It returns right away when
+// no TTL is configured.
+func (e *Expirer) Run(ctx context.Context) {
+	if e.config.TTL <= 0 {
+		return
+	}
+	ticker := time.NewTicker(e.config.ExpiryInterval)
+	defer ticker.Stop()
+	for {
+		e.sweep(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Expirer) sweep(ctx context.Context) {
+	if e.config.ReminderBefore > 0 && e.config.ReminderBefore < e.config.TTL {
+		matches, err := e.repository.ClaimReminders(ctx, e.config.TTL-e.config.ReminderBefore)
+		if err != nil {
+			slog.Error(fmt.Sprintf("cat match reminders: %v", err))
+		}
+		for _, match := range matches {
+			err = e.reminder.RemindPending(ctx, match, e.config.expiresAt(match.Age))
+			if err != nil {
+				slog.Error(fmt.Sprintf("cat match %s reminder: %v", match.UID, err))
+			}
+		}
+	}
+
+	expired, err := e.repository.ExpirePending(ctx, e.config.TTL)
+	if err != nil {
+		slog.Error(fmt.Sprintf("cat match expiry: %v", err))
+		return
+	}
+	if len(expired) > 0 {
+		slog.Info(fmt.Sprintf("expired %d pending cat matches", len(expired)))
+	}
+}
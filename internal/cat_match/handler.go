@@ -11,6 +11,7 @@ import (
 	"github.com/citadel-corp/cats-social/internal/common/request"
 	"github.com/citadel-corp/cats-social/internal/common/response"
 	"github.com/gorilla/mux"
+	"github.com/gorilla/schema"
 )
 
 type Handler struct {
@@ -48,7 +49,7 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.service.Create(r.Context(), req, userID)
+	match, err := h.service.Create(r.Context(), req, userID)
 	if errors.Is(err, cat.ErrCatNotFound) {
 		response.JSON(w, http.StatusNotFound, response.ResponseBody{
 			Message: "Not found",
@@ -77,6 +78,28 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	if errors.Is(err, ErrCatVersionChanged) || errors.Is(err, ErrMatchAlreadyRequested) || errors.Is(err, ErrReciprocalMatch) || errors.Is(err, ErrMatchCooldown) {
+		response.JSON(w, http.StatusConflict, response.ResponseBody{
+			Message: "Conflict",
+			Error:   err.Error(),
+		})
+		return
+	}
+	// approving a reciprocal request can lose a race with its reject, withdraw or expiry
+	if errors.Is(err, ErrCatMatchNoLongerValid) {
+		response.JSON(w, http.StatusConflict, response.ResponseBody{
+			Message: "Conflict",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, ErrCatMatchForbidden) {
+		response.JSON(w, http.StatusForbidden, response.ResponseBody{
+			Message: "Forbidden",
+			Error:   err.Error(),
+		})
+		return
+	}
 
 	if err != nil {
 		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
@@ -87,6 +110,7 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 	response.JSON(w, http.StatusCreated, response.ResponseBody{
 		Message: "success",
+		Data:    match,
 	})
 }
 
@@ -118,14 +142,28 @@ func (h *Handler) Approve(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err = h.service.Approve(r.Context(), req, userID)
+	if errors.Is(err, ErrCatMatchForbidden) {
+		response.JSON(w, http.StatusForbidden, response.ResponseBody{
+			Message: "Forbidden",
+			Error:   err.Error(),
+		})
+		return
+	}
 	if errors.Is(err, ErrCatMatchNoLongerValid) {
-		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
-			Message: "Bad request",
+		response.JSON(w, http.StatusConflict, response.ResponseBody{
+			Message: "Conflict",
 			Error:   err.Error(),
 		})
 		return
 	}
-	if errors.Is(err, ErrCatMatchNotFound) {
+	if errors.Is(err, ErrCatHasMatched) {
+		response.JSON(w, http.StatusConflict, response.ResponseBody{
+			Message: "Conflict",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, ErrCatMatchNotFound) || errors.Is(err, cat.ErrCatNotFound) {
 		response.JSON(w, http.StatusNotFound, response.ResponseBody{
 			Message: "Not found",
 			Error:   err.Error(),
@@ -173,9 +211,16 @@ func (h *Handler) Reject(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err = h.service.Reject(r.Context(), req, userID)
+	if errors.Is(err, ErrCatMatchForbidden) {
+		response.JSON(w, http.StatusForbidden, response.ResponseBody{
+			Message: "Forbidden",
+			Error:   err.Error(),
+		})
+		return
+	}
 	if errors.Is(err, ErrCatMatchNoLongerValid) {
-		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
-			Message: "Bad request",
+		response.JSON(w, http.StatusConflict, response.ResponseBody{
+			Message: "Conflict",
 			Error:   err.Error(),
 		})
 		return
@@ -200,7 +245,7 @@ func (h *Handler) Reject(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) Withdraw(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserID(r)
 	if err != nil {
 		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{})
@@ -210,14 +255,84 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id := params["id"]
 
-	err = h.service.Delete(r.Context(), id, userID)
+	err = h.service.Withdraw(r.Context(), id, userID)
+	if errors.Is(err, ErrCatMatchForbidden) {
+		response.JSON(w, http.StatusForbidden, response.ResponseBody{
+			Message: "Forbidden",
+			Error:   err.Error(),
+		})
+		return
+	}
 	if errors.Is(err, ErrCatMatchNoLongerValid) {
+		response.JSON(w, http.StatusConflict, response.ResponseBody{
+			Message: "Conflict",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, ErrCatMatchNotFound) {
+		response.JSON(w, http.StatusNotFound, response.ResponseBody{
+			Message: "Not found",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
+			Message: "Internal server error",
+			Error:   err.Error(),
+		})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.ResponseBody{
+		Message: "success",
+	})
+}
+
+func (h *Handler) Dissolve(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{})
+		return
+	}
+
+	params := mux.Vars(r)
+	id := params["id"]
+
+	var req DissolveMatch
+
+	err = request.DecodeJSON(w, r, &req)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
+			Message: "Failed to decode JSON",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	err = h.service.Dissolve(r.Context(), req, id, userID)
+	if errors.Is(err, ErrValidationFailed) {
 		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
 			Message: "Bad request",
 			Error:   err.Error(),
 		})
 		return
 	}
+	if errors.Is(err, ErrCatMatchForbidden) {
+		response.JSON(w, http.StatusForbidden, response.ResponseBody{
+			Message: "Forbidden",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, ErrCatMatchNoLongerValid) {
+		response.JSON(w, http.StatusConflict, response.ResponseBody{
+			Message: "Conflict",
+			Error:   err.Error(),
+		})
+		return
+	}
 	if errors.Is(err, ErrCatMatchNotFound) {
 		response.JSON(w, http.StatusNotFound, response.ResponseBody{
 			Message: "Not found",
@@ -244,7 +359,57 @@ func (h *Handler) GetCatMatchList(w http.ResponseWriter, r *http.Request) {
 		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{})
 		return
 	}
-	cats, err := h.service.List(r.Context(), userID)
+
+	newSchema := schema.NewDecoder()
+	newSchema.IgnoreUnknownKeys(true)
+
+	var req ListCatMatchPayload
+	if err = newSchema.Decode(&req, r.URL.Query()); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
+			Message: "Bad request",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	matches, meta, err := h.service.List(r.Context(), req, userID)
+	if errors.Is(err, ErrValidationFailed) || errors.Is(err, ErrInvalidCursor) {
+		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
+			Message: "Bad request",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
+			Message: "Internal server error",
+			Error:   err.Error(),
+		})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.ResponseBody{
+		Message: "success",
+		Data:    matches,
+		Meta:    meta,
+	})
+}
+
+func (h *Handler) MarkViewed(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{})
+		return
+	}
+	params := mux.Vars(r)
+	id := params["id"]
+	err = h.service.MarkViewed(r.Context(), id, userID)
+	if errors.Is(err, ErrCatMatchNotFound) {
+		response.JSON(w, http.StatusNotFound, response.ResponseBody{
+			Message: "Not found",
+			Error:   err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
 			Message: "Internal server error",
@@ -254,7 +419,6 @@ func (h *Handler) GetCatMatchList(w http.ResponseWriter, r *http.Request) {
 	}
 	response.JSON(w, http.StatusOK, response.ResponseBody{
 		Message: "success",
-		Data:    cats,
 	})
 }
 
@@ -4,20 +4,36 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/citadel-corp/cats-social/internal/cat"
 	"github.com/citadel-corp/cats-social/internal/common/db"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/lib/pq"
 )
 
 type Repository interface {
 	Create(ctx context.Context, catMatch *CatMatches) error
-	Approve(ctx context.Context, catMatch *CatMatches) error
-	Reject(ctx context.Context, catMatch *CatMatches) error
-	Delete(ctx context.Context, id int64, userId int64) error
+	// the status changing methods move catMatch from the status it was read with to status
+	Approve(ctx context.Context, catMatch *CatMatches, status MatchStatus) error
+	Reject(ctx context.Context, catMatch *CatMatches, status MatchStatus) error
+	Withdraw(ctx context.Context, catMatch *CatMatches, status MatchStatus) error
+	Expire(ctx context.Context, catMatch *CatMatches, status MatchStatus) error
+	Dissolve(ctx context.Context, catMatch *CatMatches, status MatchStatus, actorID int64) error
 	// GetByCatID(ctx context.Context, catID int64) (*CatMatches, error)
-	GetByUIDAndUserID(ctx context.Context, uid string, userID int64, filter map[string]interface{}) (*CatMatches, error)
+	GetByUIDAndUserID(ctx context.Context, uid string, userID int64) (*CatMatches, error)
+	GetPendingBetween(ctx context.Context, catID int64, otherCatID int64) (*CatMatches, error)
+	GetCooldownLeft(ctx context.Context, catID int64, otherCatID int64, cooldown time.Duration) (time.Duration, error)
+	ExpirePending(ctx context.Context, ttl time.Duration) ([]CatMatches, error)
+	ClaimReminders(ctx context.Context, olderThan time.Duration) ([]CatMatches, error)
 	// GetMatchingCats(ctx context.Context, matchUid string) (*CatMatchAndCats, error)
-	List(ctx context.Context, userID int64, filter map[string]interface{}) ([]CatMatchList, error)
+	List(ctx context.Context, userID int64, req ListCatMatchPayload) ([]CatMatchList, error)
+	Count(ctx context.Context, userID int64, req ListCatMatchPayload) (*CatMatchCounts, error)
+	MarkViewed(ctx context.Context, uid string, userID int64) error
+	CountByUserID(ctx context.Context, userID int64) (int, error)
+	EachByUserID(ctx context.Context, userID int64, fn func(*CatMatchHistory) error) error
 }
 
 type dbRepository struct {
@@ -31,100 +47,258 @@ func NewRepository(db *db.DB) Repository {
 func (d *dbRepository) Create(ctx context.Context, catMatch *CatMatches) error {
 	createCatQuery := `
         INSERT INTO cat_matches (
-            uid, issuer_cat_id, issuer_user_id, matched_cat_id, matched_user_id, message
+            uid, issuer_cat_id, issuer_user_id, matched_cat_id, matched_user_id, message,
+            issuer_cat_version, matched_cat_version
         ) VALUES (
-            $1, $2, $3, $4, $5, $6
+            $1, $2, $3, $4, $5, $6, $7, $8
         );
     `
 
-	_, err := d.db.DB().ExecContext(ctx, createCatQuery,
-		catMatch.UID, catMatch.IssuerCatId, catMatch.IssueUserId,
-		catMatch.MatchCatId, catMatch.MatchUserId, catMatch.Message)
+	return d.db.StartTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, createCatQuery,
+			catMatch.UID, catMatch.IssuerCatId, catMatch.IssueUserId,
+			catMatch.MatchCatId, catMatch.MatchUserId, catMatch.Message,
+			catMatch.IssuerCatVersion, catMatch.MatchCatVersion)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrMatchAlreadyRequested
+		}
+		if err != nil {
+			return err
+		}
+		return RecordEvent(ctx, tx, *catMatch, Pending, catMatch.IssueUserId)
+	})
+}
+
+// GetPendingBetween implements Repository. Finds the pending match between two cats in either direction.
+func (d *dbRepository) GetPendingBetween(ctx context.Context, catID int64, otherCatID int64) (*CatMatches, error) {
+	getMatchQuery := fmt.Sprintf(`
+		SELECT id, uid, issuer_cat_id, issuer_user_id, matched_cat_id, matched_user_id, message, approval_status, created_at, %s
+		FROM cat_matches
+		WHERE LEAST(issuer_cat_id, matched_cat_id) = LEAST($1::INT, $2::INT)
+		AND GREATEST(issuer_cat_id, matched_cat_id) = GREATEST($1::INT, $2::INT)
+		AND approval_status = $3;
+	`, ageSQL("created_at"))
+
+	row := d.db.DB().QueryRowContext(ctx, getMatchQuery, catID, otherCatID, Pending)
+	catMatch := &CatMatches{}
+	err := row.Scan(&catMatch.ID, &catMatch.UID, &catMatch.IssuerCatId, &catMatch.IssueUserId,
+		&catMatch.MatchCatId, &catMatch.MatchUserId, &catMatch.Message, &catMatch.ApprovalStatus, &catMatch.CreatedAt, &catMatch.Age)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCatMatchNotFound
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return catMatch, nil
 }
 
-func (d *dbRepository) Approve(ctx context.Context, catMatch *CatMatches) error {
-	err := d.db.StartTx(ctx, func(tx *sql.Tx) error {
-		updateMatchQuery := `
-			UPDATE cat_matches
-			SET approval_status = $1
-			WHERE id = $2;
-		`
+// GetCooldownLeft implements Repository. Returns how much of cooldown is left since a match between two cats,
+// in either direction, was last dissolved, zero or less if it has run out or none was.
+func (d *dbRepository) GetCooldownLeft(ctx context.Context, catID int64, otherCatID int64, cooldown time.Duration) (time.Duration, error) {
+	getCooldownQuery := `
+		SELECT COALESCE((EXTRACT(EPOCH FROM MAX(dissolved_at) + make_interval(secs => $3) - current_timestamp) * 1000000000)::BIGINT, 0)
+		FROM cat_matches
+		WHERE LEAST(issuer_cat_id, matched_cat_id) = LEAST($1::INT, $2::INT)
+		AND GREATEST(issuer_cat_id, matched_cat_id) = GREATEST($1::INT, $2::INT)
+		AND dissolved_at IS NOT NULL;
+	`
+
+	var left time.Duration
+	err := d.db.DB().QueryRowContext(ctx, getCooldownQuery, catID, otherCatID, cooldown.Seconds()).Scan(&left)
+	if err != nil {
+		return 0, err
+	}
+	return left, nil
+}
 
-		_, err := d.db.DB().ExecContext(ctx, updateMatchQuery, Approved, catMatch.ID)
+// Approve implements Repository. Both cats and then the match are locked before anything is checked, so
+// two approvals involving the same cat are serialized and the second one sees the cat in an active match.
+// Approving pairs both cats from now on, their other pending matches are left for later.
+func (d *dbRepository) Approve(ctx context.Context, catMatch *CatMatches, status MatchStatus) error {
+	return d.db.StartTx(ctx, func(tx *sql.Tx) error {
+		// cats are locked before the match, in id order, like every other change taking both locks, so
+		// approvals sharing a cat queue on the cat instead of deadlocking on each other's matches
+		lockCatsQuery := `
+			SELECT id
+			FROM cats
+			WHERE id = ANY($1)
+			ORDER BY id
+			FOR UPDATE;
+		`
+		catIDs := pq.Array([]int64{catMatch.IssuerCatId, catMatch.MatchCatId})
+		rows, err := tx.QueryContext(ctx, lockCatsQuery, catIDs)
 		if err != nil {
 			return err
 		}
+		locked := 0
+		for rows.Next() {
+			locked += 1
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return err
+		}
+		if locked != 2 {
+			return cat.ErrCatNotFound
+		}
 
-		// update cats' matched status
-		updateCatQuery := `
-			UPDATE cats
-			SET has_matched = true
-			WHERE id = $1;
+		var current MatchStatus
+		lockMatchQuery := `
+			SELECT approval_status
+			FROM cat_matches
+			WHERE id = $1
+			FOR UPDATE;
 		`
-		_, err = d.db.DB().ExecContext(ctx, updateCatQuery, catMatch.IssuerCatId)
+		err = tx.QueryRowContext(ctx, lockMatchQuery, catMatch.ID).Scan(&current)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCatMatchNotFound
+		}
 		if err != nil {
 			return err
 		}
+		if current != catMatch.ApprovalStatus {
+			return fmt.Errorf("%w: cannot approve a match that is %s", ErrCatMatchNoLongerValid, current)
+		}
 
-		_, err = d.db.DB().ExecContext(ctx, updateCatQuery, catMatch.MatchCatId)
+		// checked once the cats are locked, so pairings made by approvals that held the lock are seen
+		var hasMatched bool
+		activePairingQuery := `
+			SELECT EXISTS (SELECT 1 FROM cat_pairings WHERE cat_id = ANY($1) AND ended_at IS NULL);
+		`
+		err = tx.QueryRowContext(ctx, activePairingQuery, catIDs).Scan(&hasMatched)
 		if err != nil {
 			return err
 		}
+		if hasMatched {
+			return ErrCatHasMatched
+		}
 
-		// reject each cat's remaining matches
-		deleteMatchQuery := `
-            UPDATE cat_matches
-			SET approval_status = $1
-            WHERE (issuer_cat_id = $2 OR matched_cat_id = $2) AND id != $3;
-        `
-		_, err = d.db.DB().ExecContext(ctx, deleteMatchQuery, Rejected, catMatch.IssuerCatId, catMatch.ID)
+		updateMatchQuery := `
+			UPDATE cat_matches
+			SET approval_status = $1, responded_at = current_timestamp,
+			receiver_viewed_at = COALESCE(receiver_viewed_at, current_timestamp)
+			WHERE id = $2;
+		`
+		_, err = tx.ExecContext(ctx, updateMatchQuery, status, catMatch.ID)
 		if err != nil {
 			return err
 		}
 
-		_, err = d.db.DB().ExecContext(ctx, deleteMatchQuery, Rejected, catMatch.MatchCatId, catMatch.ID)
+		// one pairing per cat, the unique index on active pairings backs the check above
+		createPairingsQuery := `
+			INSERT INTO cat_pairings (match_id, cat_id, partner_cat_id)
+			VALUES ($1, $2, $3), ($1, $3, $2);
+		`
+		_, err = tx.ExecContext(ctx, createPairingsQuery, catMatch.ID, catMatch.IssuerCatId, catMatch.MatchCatId)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrCatHasMatched
+		}
 		if err != nil {
 			return err
 		}
-
-		return nil
-
+		return RecordEvent(ctx, tx, *catMatch, status, catMatch.MatchUserId)
 	})
+}
 
-	return err
+// Reject implements Repository.
+func (d *dbRepository) Reject(ctx context.Context, catMatch *CatMatches, status MatchStatus) error {
+	rejectMatchQuery := `
+		UPDATE cat_matches
+		SET approval_status = $1, responded_at = current_timestamp,
+		receiver_viewed_at = COALESCE(receiver_viewed_at, current_timestamp)
+		WHERE id = $2 AND approval_status = $3;
+	`
+	return d.updateStatus(ctx, rejectMatchQuery, status, catMatch, catMatch.MatchUserId)
 }
 
-func (d *dbRepository) Reject(ctx context.Context, catMatch *CatMatches) error {
-	updateMatchQuery := `
+// Withdraw implements Repository.
+func (d *dbRepository) Withdraw(ctx context.Context, catMatch *CatMatches, status MatchStatus) error {
+	withdrawMatchQuery := `
 		UPDATE cat_matches
-		SET approval_status = $1
-		WHERE id = $2;
+		SET approval_status = $1, responded_at = current_timestamp
+		WHERE id = $2 AND approval_status = $3;
 	`
+	return d.updateStatus(ctx, withdrawMatchQuery, status, catMatch, catMatch.IssueUserId)
+}
 
-	_, err := d.db.DB().ExecContext(ctx, updateMatchQuery, Rejected, catMatch.ID)
-	if err != nil {
-		return err
-	}
+// Expire implements Repository. Expires a single match, ExpirePending sweeps all of them.
+func (d *dbRepository) Expire(ctx context.Context, catMatch *CatMatches, status MatchStatus) error {
+	expireMatchQuery := `
+		UPDATE cat_matches
+		SET approval_status = $1, responded_at = current_timestamp
+		WHERE id = $2 AND approval_status = $3;
+	`
+	return d.updateStatus(ctx, expireMatchQuery, status, catMatch, 0)
+}
 
-	return nil
+// Dissolve implements Repository. The match is dissolved with its reason and the pairing of both cats
+// ends in the same transaction, so they are free to match again.
+func (d *dbRepository) Dissolve(ctx context.Context, catMatch *CatMatches, status MatchStatus, actorID int64) error {
+	return d.db.StartTx(ctx, func(tx *sql.Tx) error {
+		dissolveMatchQuery := `
+			UPDATE cat_matches
+			SET approval_status = $1, dissolved_at = current_timestamp, dissolved_by_user_id = $2, dissolve_reason = $3
+			WHERE id = $4 AND approval_status = $5;
+		`
+		res, err := tx.ExecContext(ctx, dissolveMatchQuery, status, actorID, catMatch.DissolveReason, catMatch.ID, catMatch.ApprovalStatus)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrCatMatchNoLongerValid
+		}
+
+		endPairingQuery := `
+			UPDATE cat_pairings
+			SET ended_at = current_timestamp
+			WHERE match_id = $1 AND ended_at IS NULL;
+		`
+		_, err = tx.ExecContext(ctx, endPairingQuery, catMatch.ID)
+		if err != nil {
+			return err
+		}
+		return RecordEvent(ctx, tx, *catMatch, status, actorID)
+	})
+}
+
+// updateStatus runs a status update guarded on the status the match was read with, so a match changed
+// concurrently is reported as no longer valid instead of being overwritten.
+func (d *dbRepository) updateStatus(ctx context.Context, query string, status MatchStatus, catMatch *CatMatches, actorID int64) error {
+	return d.db.StartTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, query, status, catMatch.ID, catMatch.ApprovalStatus)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrCatMatchNoLongerValid
+		}
+		return RecordEvent(ctx, tx, *catMatch, status, actorID)
+	})
 }
 
-func (d *dbRepository) GetByUIDAndUserID(ctx context.Context, uid string, userID int64, filter map[string]interface{}) (*CatMatches, error) {
-	getMatchQuery := `
-		SELECT id, uid, issuer_cat_id, issuer_user_id, matched_cat_id, matched_user_id, message, approval_status
+// GetByUIDAndUserID implements Repository. The match is found for both its issuer and its receiver.
+func (d *dbRepository) GetByUIDAndUserID(ctx context.Context, uid string, userID int64) (*CatMatches, error) {
+	getMatchQuery := fmt.Sprintf(`
+		SELECT id, uid, issuer_cat_id, issuer_user_id, matched_cat_id, matched_user_id, message, approval_status, created_at, %s
 		FROM cat_matches
-		WHERE uid = $1 AND issuer_user_id = $2;
-	`
+		WHERE uid = $1 AND (issuer_user_id = $2 OR matched_user_id = $2);
+	`, ageSQL("created_at"))
 
 	row := d.db.DB().QueryRowContext(ctx, getMatchQuery, uid, userID)
 	catMatch := &CatMatches{}
 	err := row.Scan(&catMatch.ID, &catMatch.UID, &catMatch.IssuerCatId, &catMatch.IssueUserId,
-		&catMatch.MatchCatId, &catMatch.MatchUserId, &catMatch.Message, &catMatch.ApprovalStatus)
+		&catMatch.MatchCatId, &catMatch.MatchUserId, &catMatch.Message, &catMatch.ApprovalStatus, &catMatch.CreatedAt, &catMatch.Age)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrCatMatchNotFound
 	}
@@ -132,68 +306,256 @@ func (d *dbRepository) GetByUIDAndUserID(ctx context.Context, uid string, userID
 		return nil, err
 	}
 
-	if v, ok := filter["pending_only"].(bool); v && ok && catMatch.ApprovalStatus != Pending {
-		return nil, ErrCatMatchNoLongerValid
-	}
-
 	return catMatch, nil
 }
 
-func (d *dbRepository) Delete(ctx context.Context, id int64, userId int64) error {
-	deleteMatchQuery := `
-		DELETE FROM cat_matches
-		WHERE id = $1 AND issuer_user_id = $2;
-	`
+// ExpirePending implements Repository. Expires every pending match older than ttl and returns them.
+func (d *dbRepository) ExpirePending(ctx context.Context, ttl time.Duration) ([]CatMatches, error) {
+	from, to := SystemTransition(ActionExpire)
+	expireQuery := fmt.Sprintf(`
+		UPDATE cat_matches
+		SET approval_status = $1, responded_at = current_timestamp
+		WHERE approval_status = $2 AND created_at < current_timestamp - make_interval(secs => $3)
+		RETURNING id, uid, issuer_cat_id, issuer_user_id, matched_cat_id, matched_user_id, message, approval_status, created_at, %s;
+	`, ageSQL("created_at"))
+	var expired []CatMatches
+	err := d.db.StartTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, expireQuery, to, from, ttl.Seconds())
+		if err != nil {
+			return err
+		}
+		expired, err = scanMatches(rows)
+		if err != nil {
+			return err
+		}
+		for _, match := range expired {
+			err = RecordEvent(ctx, tx, match, to, 0)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return expired, nil
+}
 
-	_, err := d.db.DB().ExecContext(ctx, deleteMatchQuery, id, userId)
+// ClaimReminders implements Repository. Marks pending matches older than olderThan as reminded and returns
+// them, so each match is reminded at most once even with several instances running.
+func (d *dbRepository) ClaimReminders(ctx context.Context, olderThan time.Duration) ([]CatMatches, error) {
+	claimQuery := fmt.Sprintf(`
+		UPDATE cat_matches
+		SET reminded_at = current_timestamp
+		WHERE approval_status = $1 AND reminded_at IS NULL AND created_at < current_timestamp - make_interval(secs => $2)
+		RETURNING id, uid, issuer_cat_id, issuer_user_id, matched_cat_id, matched_user_id, message, approval_status, created_at, %s;
+	`, ageSQL("created_at"))
+	rows, err := d.db.DB().QueryContext(ctx, claimQuery, Pending, olderThan.Seconds())
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return scanMatches(rows)
+}
 
-	return nil
+// ageSQL selects how long ago a timestamp column was set, in nanoseconds by the database clock. The columns
+// have no time zone, compared with the Go clock they would be off by the database's offset from UTC.
+func ageSQL(column string) string {
+	return fmt.Sprintf("COALESCE((EXTRACT(EPOCH FROM current_timestamp - %s) * 1000000000)::BIGINT, 0)", column)
 }
 
-// List implements Repository.
-func (d *dbRepository) List(ctx context.Context, userID int64, filter map[string]interface{}) ([]CatMatchList, error) {
-	listQuery := `
-		SELECT cm.uid, cm.message, cm.created_at,
+// scanMatches reads rows of the match columns selected by GetByUIDAndUserID.
+func scanMatches(rows *sql.Rows) ([]CatMatches, error) {
+	defer rows.Close()
+	res := make([]CatMatches, 0)
+	for rows.Next() {
+		catMatch := CatMatches{}
+		err := rows.Scan(&catMatch.ID, &catMatch.UID, &catMatch.IssuerCatId, &catMatch.IssueUserId,
+			&catMatch.MatchCatId, &catMatch.MatchUserId, &catMatch.Message, &catMatch.ApprovalStatus, &catMatch.CreatedAt, &catMatch.Age)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, catMatch)
+	}
+	return res, rows.Err()
+}
+
+// List implements Repository. Matches are listed newest first, starting after req.After when set.
+func (d *dbRepository) List(ctx context.Context, userID int64, req ListCatMatchPayload) ([]CatMatchList, error) {
+	filter := newMatchFilter(userID, req)
+	switch req.Direction {
+	case Incoming:
+		filter.add("cm.matched_user_id = $1")
+	case Outgoing:
+		filter.add("cm.issuer_user_id = $1")
+	}
+	if req.After != nil {
+		filter.add("(cm.created_at, cm.id) < (%s::TIMESTAMP, %s)", req.After.Timestamp(), req.After.ID)
+	}
+	listQuery := fmt.Sprintf(`
+		SELECT cm.id, cm.uid, cm.message, cm.approval_status, cm.receiver_viewed_at, cm.created_at, %s, cm.issuer_cat_version, cm.matched_cat_version,
+		COALESCE(cm.dissolve_reason, ''), pr.started_at, pr.ended_at,
 		ic.uid, ic.name, ic.race, ic.sex, ic.description, ic.age_in_month,
-		ic.image_urls, ic.has_matched, ic.created_at,
+		ARRAY(SELECT ci.url FROM cat_images ci WHERE ci.cat_id = ic.id ORDER BY ci.position), %s, ic.version, ic.created_at,
 		mc.uid, mc.name, mc.race, mc.sex, mc.description, mc.age_in_month,
-		mc.image_urls, mc.has_matched, mc.created_at,
+		ARRAY(SELECT ci.url FROM cat_images ci WHERE ci.cat_id = mc.id ORDER BY ci.position), %s, mc.version, mc.created_at,
 		u.id, u.name, u.email, u.created_at
 		FROM cat_matches cm
 		LEFT JOIN cats ic on cm.issuer_cat_id = ic.id
 		LEFT JOIN cats mc on cm.matched_cat_id = mc.id
 		LEFT JOIN users u on cm.issuer_user_id = u.id
-		WHERE (cm.issuer_user_id = $1 OR cm.matched_user_id = $1)
-		ORDER BY cm.created_at desc;
-	`
+		LEFT JOIN cat_pairings pr on pr.match_id = cm.id AND pr.cat_id = cm.issuer_cat_id
+		WHERE %s
+		ORDER BY cm.created_at DESC, cm.id DESC
+		LIMIT %d;
+	`, ageSQL("cm.created_at"), cat.HasMatchedSQL("ic.id"), cat.HasMatchedSQL("mc.id"), filter.where(), req.Limit)
 
-	// var approvalStatus MatchStatus
-	// if v, ok := filter["approval_status"].(MatchStatus); ok {
-	// 	approvalStatus = v
-	// } else {
-	// 	approvalStatus = Approved
-	// }
-
-	rows, err := d.db.DB().QueryContext(ctx, listQuery, userID)
+	rows, err := d.db.DB().QueryContext(ctx, listQuery, filter.params...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 	res := make([]CatMatchList, 0)
 	for rows.Next() {
 		catMatch := CatMatchList{}
-		err = rows.Scan(&catMatch.ID, &catMatch.Message, &catMatch.CreatedAt,
+		var viewedAt, pairedAt, unpairedAt sql.NullTime
+		err = rows.Scan(&catMatch.MatchID, &catMatch.ID, &catMatch.Message, &catMatch.Status, &viewedAt, &catMatch.CreatedAt, &catMatch.Age, &catMatch.IssuerCatVersion, &catMatch.MatchCatVersion,
+			&catMatch.DissolveReason, &pairedAt, &unpairedAt,
 			&catMatch.IssuerCat.ID, &catMatch.IssuerCat.Name, &catMatch.IssuerCat.Race, &catMatch.IssuerCat.Sex, &catMatch.IssuerCat.Description, &catMatch.IssuerCat.AgeInMonth,
-			pq.Array(&catMatch.IssuerCat.ImageUrls), &catMatch.IssuerCat.HasMatched, &catMatch.IssuerCat.CreatedAt,
+			pq.Array(&catMatch.IssuerCat.ImageUrls), &catMatch.IssuerCat.HasMatched, &catMatch.IssuerCat.Version, &catMatch.IssuerCat.CreatedAt,
 			&catMatch.MatchCat.ID, &catMatch.MatchCat.Name, &catMatch.MatchCat.Race, &catMatch.MatchCat.Sex, &catMatch.MatchCat.Description, &catMatch.MatchCat.AgeInMonth,
-			pq.Array(&catMatch.MatchCat.ImageUrls), &catMatch.MatchCat.HasMatched, &catMatch.MatchCat.CreatedAt,
+			pq.Array(&catMatch.MatchCat.ImageUrls), &catMatch.MatchCat.HasMatched, &catMatch.MatchCat.Version, &catMatch.MatchCat.CreatedAt,
 			&catMatch.IssuedBy.ID, &catMatch.IssuedBy.Name, &catMatch.IssuedBy.Email, &catMatch.IssuedBy.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
+		if viewedAt.Valid {
+			catMatch.ReceiverViewedAt = &viewedAt.Time
+		}
+		if pairedAt.Valid {
+			catMatch.PairedAt = &pairedAt.Time
+		}
+		if unpairedAt.Valid {
+			catMatch.UnpairedAt = &unpairedAt.Time
+		}
 		res = append(res, catMatch)
 	}
-	return res, nil
+	return res, rows.Err()
+}
+
+// Count implements Repository. Counts follow the status and cat filters of req but not its direction or cursor.
+func (d *dbRepository) Count(ctx context.Context, userID int64, req ListCatMatchPayload) (*CatMatchCounts, error) {
+	filter := newMatchFilter(userID, req)
+	countQuery := fmt.Sprintf(`
+		SELECT
+		COUNT(*) FILTER (WHERE cm.matched_user_id = $1),
+		COUNT(*) FILTER (WHERE cm.issuer_user_id = $1),
+		COUNT(*) FILTER (WHERE cm.matched_user_id = $1 AND cm.receiver_viewed_at IS NULL)
+		FROM cat_matches cm
+		WHERE %s;
+	`, filter.where())
+	counts := &CatMatchCounts{}
+	err := d.db.DB().QueryRowContext(ctx, countQuery, filter.params...).Scan(&counts.Incoming, &counts.Outgoing, &counts.UnreadIncoming)
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// MarkViewed implements Repository. Only the receiver of a match can view it; viewing twice keeps the first time.
+func (d *dbRepository) MarkViewed(ctx context.Context, uid string, userID int64) error {
+	markViewedQuery := `
+		UPDATE cat_matches
+		SET receiver_viewed_at = COALESCE(receiver_viewed_at, current_timestamp)
+		WHERE uid = $1 AND matched_user_id = $2;
+	`
+	res, err := d.db.DB().ExecContext(ctx, markViewedQuery, uid, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrCatMatchNotFound
+	}
+	return nil
+}
+
+// matchFilter builds the WHERE clause of a match listing. $1 is always the user's id.
+type matchFilter struct {
+	conditions []string
+	params     []any
+}
+
+func newMatchFilter(userID int64, req ListCatMatchPayload) *matchFilter {
+	f := &matchFilter{params: []any{userID}}
+	f.add("(cm.issuer_user_id = $1 OR cm.matched_user_id = $1)")
+	if req.Status != "" {
+		f.add("cm.approval_status = %s", req.Status)
+	}
+	if req.CatID != "" {
+		f.add("(cm.issuer_cat_id = (SELECT id FROM cats WHERE uid = %[1]s) OR cm.matched_cat_id = (SELECT id FROM cats WHERE uid = %[1]s))", req.CatID)
+	}
+	return f
+}
+
+// add appends a condition, binding each value to the matching %s in condition.
+func (f *matchFilter) add(condition string, values ...any) {
+	placeholders := make([]any, len(values))
+	for i, value := range values {
+		f.params = append(f.params, value)
+		placeholders[i] = fmt.Sprintf("$%d", len(f.params))
+	}
+	f.conditions = append(f.conditions, fmt.Sprintf(condition, placeholders...))
+}
+
+func (f *matchFilter) where() string {
+	return strings.Join(f.conditions, " AND ")
+}
+
+// CountByUserID implements Repository.
+func (d *dbRepository) CountByUserID(ctx context.Context, userID int64) (int, error) {
+	countQuery := `
+		SELECT COUNT(*)
+		FROM cat_matches
+		WHERE issuer_user_id = $1 OR matched_user_id = $1;
+	`
+	var count int
+	err := d.db.DB().QueryRowContext(ctx, countQuery, userID).Scan(&count)
+	return count, err
+}
+
+// EachByUserID implements Repository. Rows are handed to fn one at a time so callers can stream them.
+func (d *dbRepository) EachByUserID(ctx context.Context, userID int64, fn func(*CatMatchHistory) error) error {
+	listQuery := `
+		SELECT cm.uid, cm.issuer_user_id, ic.uid, ic.name, cm.matched_user_id, mc.uid, mc.name,
+		cm.message, cm.approval_status, cm.created_at
+		FROM cat_matches cm
+		LEFT JOIN cats ic on cm.issuer_cat_id = ic.id
+		LEFT JOIN cats mc on cm.matched_cat_id = mc.id
+		WHERE (cm.issuer_user_id = $1 OR cm.matched_user_id = $1)
+		ORDER BY cm.created_at ASC;
+	`
+	rows, err := d.db.DB().QueryContext(ctx, listQuery, userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var issuerCatUID, issuerCatName, matchCatUID, matchCatName sql.NullString
+		match := &CatMatchHistory{}
+		err = rows.Scan(&match.UID, &match.IssuerUserId, &issuerCatUID, &issuerCatName, &match.MatchUserId, &matchCatUID, &matchCatName,
+			&match.Message, &match.ApprovalStatus, &match.CreatedAt)
+		if err != nil {
+			return err
+		}
+		match.IssuerCatUID, match.IssuerCatName = issuerCatUID.String, issuerCatName.String
+		match.MatchCatUID, match.MatchCatName = matchCatUID.String, matchCatName.String
+		if err = fn(match); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
 }
@@ -1,11 +1,16 @@
 package catmatch
 
-import validation "github.com/go-ozzo/ozzo-validation/v4"
+import (
+	"github.com/citadel-corp/cats-social/internal/common/cursor"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
 
 type PostCatMatch struct {
 	MatchCatId string `json:"matchCatId"`
 	UserCatId  string `json:"userCatId"`
 	Message    string `json:"message"`
+	// MatchCatVersion optionally pins the version of the matched cat the requester saw
+	MatchCatVersion int `json:"matchCatVersion"`
 }
 
 func (p PostCatMatch) Validate() error {
@@ -13,6 +18,25 @@ func (p PostCatMatch) Validate() error {
 		validation.Field(&p.MatchCatId, validation.Required),
 		validation.Field(&p.UserCatId, validation.Required),
 		validation.Field(&p.Message, validation.Required, validation.Length(5, 120)),
+		validation.Field(&p.MatchCatVersion, validation.Min(0)),
+	)
+}
+
+type ListCatMatchPayload struct {
+	Direction string `schema:"direction" binding:"omitempty"`
+	Status    string `schema:"status" binding:"omitempty"`
+	CatID     string `schema:"catId" binding:"omitempty"`
+	Cursor    string `schema:"cursor" binding:"omitempty"`
+	Limit     int    `schema:"limit" binding:"omitempty"`
+
+	After *cursor.Cursor `schema:"-"`
+}
+
+func (p ListCatMatchPayload) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Direction, validation.In(Incoming, Outgoing)),
+		validation.Field(&p.Status, validation.In(string(Pending), string(Approved), string(Rejected), string(Cancelled), string(Withdrawn), string(Expired), string(Dissolved))),
+		validation.Field(&p.Limit, validation.Min(0), validation.Max(100)),
 	)
 }
 
@@ -35,3 +59,13 @@ func (p DeleteMatch) Validate() error {
 		validation.Field(&p.MatchUID, validation.Required),
 	)
 }
+
+type DissolveMatch struct {
+	Reason string `json:"reason"`
+}
+
+func (p DissolveMatch) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Reason, validation.Required, validation.Length(5, 200)),
+	)
+}
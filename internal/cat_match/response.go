@@ -7,12 +7,41 @@ import (
 )
 
 type CatMatchList struct {
+	MatchID   int64
 	ID        string
 	IssuedBy  Issuer
 	MatchCat  cat.CatResponse
 	IssuerCat cat.CatResponse
 	Message   string
 	CreatedAt time.Time
+	Age       time.Duration
+
+	IssuerCatVersion int
+	MatchCatVersion  int
+
+	Status           MatchStatus
+	ReceiverViewedAt *time.Time
+	DissolveReason   string
+
+	PairedAt   *time.Time
+	UnpairedAt *time.Time
+}
+
+type CreateCatMatchResponse struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	// Mutual is set when the request approved a pending request in the other direction
+	Mutual bool `json:"mutual"`
+}
+
+// MatchEventData is pushed to a participant when a match changes status.
+type MatchEventData struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	// ExpiresAt is set on reminders of pending matches about to expire
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// Reason is set when a match is dissolved
+	Reason string `json:"reason,omitempty"`
 }
 
 type CatMatchResponse struct {
@@ -21,7 +50,35 @@ type CatMatchResponse struct {
 	MatchCatDetail cat.CatResponse `json:"matchCatDetail"`
 	UserCatDetail  cat.CatResponse `json:"userCatDetail"`
 	Message        string          `json:"message"`
-	CreatedAt      time.Time       `json:"createdAt"`
+	Status         string          `json:"status"`
+	Direction      string          `json:"direction"`
+	// IsUnread is set on incoming requests the receiver has not viewed yet
+	IsUnread  bool      `json:"isUnread"`
+	CreatedAt time.Time `json:"createdAt"`
+	// ExpiresAt is set on pending matches when requests expire
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// DissolveReason is set on dissolved matches
+	DissolveReason string `json:"dissolveReason,omitempty"`
+	// PairedAt is when an approved match paired both cats, UnpairedAt when that pairing ended
+	PairedAt   *time.Time `json:"pairedAt,omitempty"`
+	UnpairedAt *time.Time `json:"unpairedAt,omitempty"`
+
+	// versions of both cats when the match was requested, compare with each cat's current version
+	MatchCatVersion int `json:"matchCatVersion"`
+	UserCatVersion  int `json:"userCatVersion"`
+}
+
+type ListCatMatchMeta struct {
+	Limit      int                    `json:"limit"`
+	NextCursor string                 `json:"nextCursor,omitempty"`
+	Counts     CatMatchCountsResponse `json:"counts"`
+}
+
+type CatMatchCountsResponse struct {
+	Total          int `json:"total"`
+	Incoming       int `json:"incoming"`
+	Outgoing       int `json:"outgoing"`
+	UnreadIncoming int `json:"unreadIncoming"`
 }
 
 type Issuer struct {
@@ -36,12 +93,17 @@ func MakeCatMatchResponse(list []CatMatchList, userId int64) []CatMatchResponse
 	for _, match := range list {
 		userCat := cat.CatResponse{}
 		matchCat := cat.CatResponse{}
+		userCatVersion, matchCatVersion := 0, 0
+		direction := Incoming
 		if match.IssuedBy.ID == userId {
+			direction = Outgoing
 			userCat = match.IssuerCat
 			matchCat = match.MatchCat
+			userCatVersion, matchCatVersion = match.IssuerCatVersion, match.MatchCatVersion
 		} else {
 			userCat = match.MatchCat
 			matchCat = match.IssuerCat
+			userCatVersion, matchCatVersion = match.MatchCatVersion, match.IssuerCatVersion
 		}
 
 		res = append(res, CatMatchResponse{
@@ -50,7 +112,16 @@ func MakeCatMatchResponse(list []CatMatchList, userId int64) []CatMatchResponse
 			MatchCatDetail: matchCat,
 			UserCatDetail:  userCat,
 			Message:        match.Message,
+			Status:         string(match.Status),
+			Direction:      direction,
+			IsUnread:       direction == Incoming && match.ReceiverViewedAt == nil,
 			CreatedAt:      match.CreatedAt,
+			DissolveReason: match.DissolveReason,
+			PairedAt:       match.PairedAt,
+			UnpairedAt:     match.UnpairedAt,
+
+			MatchCatVersion: matchCatVersion,
+			UserCatVersion:  userCatVersion,
 		})
 	}
 
@@ -2,56 +2,119 @@ package catmatch
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/citadel-corp/cats-social/internal/cat"
+	"github.com/citadel-corp/cats-social/internal/common/cursor"
 	"github.com/citadel-corp/cats-social/internal/common/id"
 )
 
 type Service interface {
-	Create(ctx context.Context, req PostCatMatch, userID int64) error
+	Create(ctx context.Context, req PostCatMatch, userID int64) (*CreateCatMatchResponse, error)
 	Approve(ctx context.Context, req ApproveOrRejectMatch, userId int64) error
 	Reject(ctx context.Context, req ApproveOrRejectMatch, userId int64) error
-	Delete(ctx context.Context, id string, userId int64) error
-	List(ctx context.Context, userID int64) ([]CatMatchResponse, error)
+	Withdraw(ctx context.Context, id string, userId int64) error
+	Dissolve(ctx context.Context, req DissolveMatch, id string, userID int64) error
+	List(ctx context.Context, req ListCatMatchPayload, userID int64) ([]CatMatchResponse, *ListCatMatchMeta, error)
+	MarkViewed(ctx context.Context, id string, userID int64) error
+}
+
+const (
+	defaultListLimit = 20
+
+	defaultDissolveCooldown = 30 * 24 * time.Hour
+)
+
+// Config holds the tunable match rules.
+type Config struct {
+	// AutoApproveReciprocal turns a request answering a pending request in the other direction into an approval
+	AutoApproveReciprocal bool
+	// TTL is how long a match stays pending before it expires, zero keeps pending matches forever
+	TTL time.Duration
+	// ReminderBefore is how long before expiry a reminder is sent, zero sends none
+	ReminderBefore time.Duration
+	// ExpiryInterval is how often pending matches are checked for expiry
+	ExpiryInterval time.Duration
+	// DissolveCooldown is how long two cats can't request each other again after dissolving their match,
+	// 30 days when zero, a negative cooldown turns it off
+	DissolveCooldown time.Duration
+}
+
+// expiresAt returns when a pending match of the given age expires, the zero time if matches don't expire.
+func (c Config) expiresAt(age time.Duration) time.Time {
+	if c.TTL <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(c.TTL - age)
+}
+
+// isExpired reports whether a match is past its TTL, even if the expirer hasn't marked it yet.
+func (c Config) isExpired(match CatMatches) bool {
+	return match.ApprovalStatus == Pending && c.TTL > 0 && match.Age > c.TTL
 }
 
 type catMatchService struct {
 	repository    Repository
 	catRepository cat.Repository
+	config        Config
 }
 
-func NewService(repository Repository, catRepository cat.Repository) Service {
-	return &catMatchService{repository: repository, catRepository: catRepository}
+// NewService creates the match Service. Participants are told about status changes from the domain
+// events the repository records, see UserEvents.
+func NewService(repository Repository, catRepository cat.Repository, config Config) Service {
+	if config.DissolveCooldown == 0 {
+		config.DissolveCooldown = defaultDissolveCooldown
+	}
+	return &catMatchService{repository: repository, catRepository: catRepository, config: config}
 }
 
-func (s *catMatchService) Create(ctx context.Context, req PostCatMatch, userID int64) error {
+// Create implements Service. A request for a pair of cats that already has a pending request in the
+// other direction is a mutual match: it approves that request when AutoApproveReciprocal is set and is
+// refused otherwise.
+func (s *catMatchService) Create(ctx context.Context, req PostCatMatch, userID int64) (*CreateCatMatchResponse, error) {
 	err := req.Validate()
 	if err != nil {
-		return ErrValidationFailed
+		return nil, ErrValidationFailed
 	}
 
 	// get issuer cat
 	issuerCat, err := s.catRepository.GetByUIDAndUserID(ctx, req.UserCatId, userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// get matched cat
-	matchedCat, err := s.catRepository.GetByUID(ctx, req.MatchCatId)
+	// get matched cat, private cats can't be matched with
+	matchedCat, err := s.catRepository.GetByUID(ctx, req.MatchCatId, userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if issuerCat.UserID == matchedCat.UserID {
-		return ErrCatSameUser
+		return nil, ErrCatSameUser
 	}
 
 	if issuerCat.Sex == matchedCat.Sex {
-		return ErrCatSameSex
+		return nil, ErrCatSameSex
 	}
 
 	if issuerCat.HasMatched || matchedCat.HasMatched {
-		return ErrCatHasMatched
+		return nil, ErrCatHasMatched
+	}
+
+	if req.MatchCatVersion != 0 && req.MatchCatVersion != matchedCat.Version {
+		return nil, ErrCatVersionChanged
+	}
+
+	err = s.checkCooldown(ctx, issuerCat.ID, matchedCat.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.checkPending(ctx, issuerCat, matchedCat, userID)
+	if res != nil || err != nil {
+		return res, err
 	}
 
 	catMatch := &CatMatches{
@@ -61,76 +124,219 @@ func (s *catMatchService) Create(ctx context.Context, req PostCatMatch, userID i
 		MatchCatId:  matchedCat.ID,
 		MatchUserId: int64(matchedCat.UserID),
 		Message:     req.Message,
+
+		IssuerCatVersion: issuerCat.Version,
+		MatchCatVersion:  matchedCat.Version,
 	}
 	err = s.repository.Create(ctx, catMatch)
+	if errors.Is(err, ErrMatchAlreadyRequested) {
+		// a request between the two cats was created concurrently, it may be a reciprocal one
+		res, err = s.checkPending(ctx, issuerCat, matchedCat, userID)
+		if res != nil || err != nil {
+			return res, err
+		}
+		return nil, ErrMatchAlreadyRequested
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return &CreateCatMatchResponse{ID: catMatch.UID, Status: string(Pending)}, nil
 }
 
-func (s *catMatchService) Approve(ctx context.Context, req ApproveOrRejectMatch, userId int64) error {
-	// get match
-	filter := map[string]interface{}{
-		"pending_only": true,
+// checkCooldown refuses a request between two cats that dissolved a match within the cooldown.
+func (s *catMatchService) checkCooldown(ctx context.Context, catID, otherCatID int64) error {
+	if s.config.DissolveCooldown < 0 {
+		return nil
 	}
-	match, err := s.repository.GetByUIDAndUserID(ctx, req.MatchUID, userId, filter)
+	left, err := s.repository.GetCooldownLeft(ctx, catID, otherCatID, s.config.DissolveCooldown)
 	if err != nil {
 		return err
 	}
+	if left > 0 {
+		until := time.Now().Add(left)
+		return fmt.Errorf("%w, they can request each other again after %s", ErrMatchCooldown, until.Format(time.RFC3339))
+	}
+	return nil
+}
 
-	err = s.repository.Approve(ctx, match)
+// checkPending looks for a pending request between the two cats. A request in the same direction is a
+// duplicate, one in the other direction is approved as a mutual match if configured to.
+func (s *catMatchService) checkPending(ctx context.Context, issuerCat, matchedCat *cat.Cat, userID int64) (*CreateCatMatchResponse, error) {
+	pending, err := s.repository.GetPendingBetween(ctx, issuerCat.ID, matchedCat.ID)
+	if errors.Is(err, ErrCatMatchNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if s.config.isExpired(*pending) {
+		// stale requests don't block new ones, expire it now rather than waiting for the expirer
+		_, status := SystemTransition(ActionExpire)
+		err = s.repository.Expire(ctx, pending, status)
+		if errors.Is(err, ErrCatMatchNoLongerValid) {
+			// the expirer or one of the owners got to it first
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if pending.IssuerCatId == issuerCat.ID {
+		return nil, ErrMatchAlreadyRequested
+	}
+	if !s.config.AutoApproveReciprocal {
+		return nil, fmt.Errorf("%w: match %s", ErrReciprocalMatch, pending.UID)
+	}
+	status, err := nextStatus(pending, ActionApprove, userID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.approve(ctx, pending, status, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &CreateCatMatchResponse{ID: pending.UID, Status: string(status), Mutual: true}, nil
+}
+
+// Approve implements Service. Only the owner of the requested cat can approve.
+func (s *catMatchService) Approve(ctx context.Context, req ApproveOrRejectMatch, userId int64) error {
+	match, status, err := s.authorize(ctx, req.MatchUID, ActionApprove, userId)
 	if err != nil {
 		return err
 	}
+	return s.approve(ctx, match, status, userId)
+}
 
+// approve approves match on behalf of userID and tells the issuer.
+func (s *catMatchService) approve(ctx context.Context, match *CatMatches, status MatchStatus, userID int64) error {
+	err := s.repository.Approve(ctx, match, status)
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
+// Reject implements Service. Only the owner of the requested cat can reject.
 func (s *catMatchService) Reject(ctx context.Context, req ApproveOrRejectMatch, userId int64) error {
-	// get match
-	filter := map[string]interface{}{
-		"pending_only": true,
+	match, status, err := s.authorize(ctx, req.MatchUID, ActionReject, userId)
+	if err != nil {
+		return err
 	}
-	match, err := s.repository.GetByUIDAndUserID(ctx, req.MatchUID, userId, filter)
+	err = s.repository.Reject(ctx, match, status)
 	if err != nil {
 		return err
 	}
+	return nil
+}
 
-	err = s.repository.Reject(ctx, match)
+// Withdraw implements Service. Only the issuer can withdraw their request.
+func (s *catMatchService) Withdraw(ctx context.Context, id string, userId int64) error {
+	match, status, err := s.authorize(ctx, id, ActionWithdraw, userId)
+	if err != nil {
+		return err
+	}
+	err = s.repository.Withdraw(ctx, match, status)
 	if err != nil {
 		return err
 	}
-
 	return nil
 }
 
-func (s *catMatchService) Delete(ctx context.Context, id string, userId int64) error {
-	// get match
-	filter := map[string]interface{}{
-		"pending_only": true,
+// Dissolve implements Service. Either owner can dissolve an approved match, the other one is told why.
+func (s *catMatchService) Dissolve(ctx context.Context, req DissolveMatch, id string, userID int64) error {
+	err := req.Validate()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrValidationFailed, err)
 	}
-	match, err := s.repository.GetByUIDAndUserID(ctx, id, userId, filter)
+	match, status, err := s.authorize(ctx, id, ActionDissolve, userID)
 	if err != nil {
 		return err
 	}
-
-	err = s.repository.Delete(ctx, match.ID, userId)
+	match.DissolveReason = req.Reason
+	err = s.repository.Dissolve(ctx, match, status, userID)
 	if err != nil {
 		return err
 	}
-
 	return nil
 }
 
-// List implements Service.
-func (s *catMatchService) List(ctx context.Context, userID int64) ([]CatMatchResponse, error) {
-	filter := map[string]interface{}{}
-	catMatches, err := s.repository.List(ctx, userID, filter)
+// authorize loads a match, checks that userID may take action on it and returns the status it moves to.
+func (s *catMatchService) authorize(ctx context.Context, uid string, action MatchAction, userID int64) (*CatMatches, MatchStatus, error) {
+	match, err := s.repository.GetByUIDAndUserID(ctx, uid, userID)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+	status, err := nextStatus(match, action, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if s.config.isExpired(*match) {
+		return nil, "", fmt.Errorf("%w: the match has expired", ErrCatMatchNoLongerValid)
+	}
+	return match, status, nil
+}
+
+// List implements Service. One extra match is read to tell whether there is a next page.
+func (s *catMatchService) List(ctx context.Context, req ListCatMatchPayload, userID int64) ([]CatMatchResponse, *ListCatMatchMeta, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrValidationFailed, err)
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultListLimit
+	}
+	if req.Cursor != "" {
+		req.After, err = cursor.Decode(req.Cursor)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
+	limit := req.Limit
+	req.Limit += 1
+	catMatches, err := s.repository.List(ctx, userID, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	counts, err := s.repository.Count(ctx, userID, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	meta := &ListCatMatchMeta{
+		Limit: limit,
+		Counts: CatMatchCountsResponse{
+			Total:          counts.Incoming + counts.Outgoing,
+			Incoming:       counts.Incoming,
+			Outgoing:       counts.Outgoing,
+			UnreadIncoming: counts.UnreadIncoming,
+		},
+	}
+	switch req.Direction {
+	case Incoming:
+		meta.Counts.Total = counts.Incoming
+	case Outgoing:
+		meta.Counts.Total = counts.Outgoing
+	}
+	if len(catMatches) > limit {
+		catMatches = catMatches[:limit]
+		last := catMatches[limit-1]
+		meta.NextCursor = cursor.Cursor{CreatedAt: last.CreatedAt, ID: last.MatchID}.Encode()
+	}
+	res := MakeCatMatchResponse(catMatches, userID)
+	if s.config.TTL > 0 {
+		for i, match := range catMatches {
+			if match.Status == Pending {
+				expiresAt := s.config.expiresAt(match.Age)
+				res[i].ExpiresAt = &expiresAt
+			}
+		}
+	}
+	return res, meta, nil
+}
 
-	return MakeCatMatchResponse(catMatches, userID), nil
+// MarkViewed implements Service.
+func (s *catMatchService) MarkViewed(ctx context.Context, id string, userID int64) error {
+	return s.repository.MarkViewed(ctx, id, userID)
 }
@@ -0,0 +1,88 @@
+package catmatch
+
+import "fmt"
+
+type MatchAction string
+
+const (
+	ActionApprove  MatchAction = "approve"
+	ActionReject   MatchAction = "reject"
+	ActionWithdraw MatchAction = "withdraw"
+	ActionExpire   MatchAction = "expire"
+	ActionDissolve MatchAction = "dissolve"
+	ActionCancel   MatchAction = "cancel"
+)
+
+type matchRole int
+
+const (
+	roleIssuer matchRole = iota
+	roleReceiver
+	// roleSystem actions are taken by the service itself, never on behalf of a user
+	roleSystem
+	// roleParticipant actions can be taken by either owner
+	roleParticipant
+)
+
+type transition struct {
+	from  MatchStatus
+	to    MatchStatus
+	actor matchRole
+}
+
+// transitions is the match state machine: the status each action moves a match from and to, and the
+// participant allowed to take it. Every status change of a match is read from here: user actions through
+// nextStatus, system actions through SystemTransition.
+var transitions = map[MatchAction]transition{
+	ActionApprove:  {from: Pending, to: Approved, actor: roleReceiver},
+	ActionReject:   {from: Pending, to: Rejected, actor: roleReceiver},
+	ActionWithdraw: {from: Pending, to: Withdrawn, actor: roleIssuer},
+	ActionExpire:   {from: Pending, to: Expired, actor: roleSystem},
+	ActionDissolve: {from: Approved, to: Dissolved, actor: roleParticipant},
+	ActionCancel:   {from: Pending, to: Cancelled, actor: roleSystem},
+}
+
+// SystemTransition returns the statuses a system action moves matches from and to.
+func SystemTransition(action MatchAction) (from, to MatchStatus) {
+	t := transitions[action]
+	if t.actor != roleSystem {
+		panic(fmt.Sprintf("match action %q is not a system action", action))
+	}
+	return t.from, t.to
+}
+
+// nextStatus validates that userID may take action on match and returns the status the match moves to.
+// Users outside the match don't learn that it exists.
+func nextStatus(match *CatMatches, action MatchAction, userID int64) (MatchStatus, error) {
+	t, ok := transitions[action]
+	if !ok {
+		return "", fmt.Errorf("unknown match action %q", action)
+	}
+	var role matchRole
+	switch userID {
+	case match.IssueUserId:
+		role = roleIssuer
+	case match.MatchUserId:
+		role = roleReceiver
+	default:
+		return "", ErrCatMatchNotFound
+	}
+	if role != t.actor && t.actor != roleParticipant {
+		if t.actor == roleSystem {
+			return "", fmt.Errorf("%w: users can't %s matches", ErrCatMatchForbidden, action)
+		}
+		if t.actor == roleIssuer {
+			return "", fmt.Errorf("%w: only the issuer can %s a match", ErrCatMatchForbidden, action)
+		}
+		return "", fmt.Errorf("%w: only the owner of the requested cat can %s a match", ErrCatMatchForbidden, action)
+	}
+	if match.ApprovalStatus != t.from {
+		return "", fmt.Errorf("%w: cannot %s a match that is %s", ErrCatMatchNoLongerValid, action, match.ApprovalStatus)
+	}
+	return t.to, nil
+}
+
+// IsOpen reports whether a match is still live, so its owners can keep talking about it.
+func (s MatchStatus) IsOpen() bool {
+	return s == Pending || s == Approved
+}
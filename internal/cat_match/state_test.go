@@ -0,0 +1,52 @@
+package catmatch
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNextStatus(t *testing.T) {
+	const (
+		issuer   int64 = 1
+		receiver int64 = 2
+		outsider int64 = 3
+	)
+	tests := []struct {
+		action MatchAction
+		status MatchStatus
+		userID int64
+		want   MatchStatus
+		err    error
+	}{
+		{ActionApprove, Pending, receiver, Approved, nil},
+		{ActionApprove, Pending, issuer, "", ErrCatMatchForbidden},
+		{ActionApprove, Pending, outsider, "", ErrCatMatchNotFound},
+		{ActionApprove, Approved, receiver, "", ErrCatMatchNoLongerValid},
+		{ActionApprove, Withdrawn, receiver, "", ErrCatMatchNoLongerValid},
+		{ActionReject, Pending, receiver, Rejected, nil},
+		{ActionReject, Pending, issuer, "", ErrCatMatchForbidden},
+		{ActionReject, Expired, receiver, "", ErrCatMatchNoLongerValid},
+		{ActionWithdraw, Pending, issuer, Withdrawn, nil},
+		{ActionWithdraw, Pending, receiver, "", ErrCatMatchForbidden},
+		{ActionWithdraw, Rejected, issuer, "", ErrCatMatchNoLongerValid},
+		{ActionDissolve, Approved, issuer, Dissolved, nil},
+		{ActionDissolve, Approved, receiver, Dissolved, nil},
+		{ActionDissolve, Approved, outsider, "", ErrCatMatchNotFound},
+		{ActionDissolve, Pending, issuer, "", ErrCatMatchNoLongerValid},
+		{ActionDissolve, Dissolved, receiver, "", ErrCatMatchNoLongerValid},
+		{ActionExpire, Pending, issuer, "", ErrCatMatchForbidden},
+		{ActionCancel, Pending, receiver, "", ErrCatMatchForbidden},
+	}
+	for _, tt := range tests {
+		match := &CatMatches{IssueUserId: issuer, MatchUserId: receiver, ApprovalStatus: tt.status}
+		got, err := nextStatus(match, tt.action, tt.userID)
+		if got != tt.want || !errors.Is(err, tt.err) || (tt.err == nil) != (err == nil) {
+			t.Errorf("%s a %s match as user %d = %q, %v, want %q, %v", tt.action, tt.status, tt.userID, got, err, tt.want, tt.err)
+		}
+	}
+
+	_, err := nextStatus(&CatMatches{IssueUserId: issuer, MatchUserId: receiver, ApprovalStatus: Pending}, "merge", issuer)
+	if err == nil {
+		t.Error("unknown action was allowed")
+	}
+}
@@ -0,0 +1,7 @@
+package catrecommendation
+
+import "errors"
+
+var (
+	ErrValidationFailed = errors.New("validation failed")
+)
@@ -0,0 +1,127 @@
+package catrecommendation
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/citadel-corp/cats-social/internal/cat"
+	"github.com/citadel-corp/cats-social/internal/common/geo"
+)
+
+const (
+	FactorBreed      = "breed"
+	FactorAge        = "age"
+	FactorDistance   = "distance"
+	FactorHealth     = "health"
+	FactorAcceptance = "acceptance"
+
+	// cats this many months apart or more get no age points
+	maxAgeGap = 60
+	// cats this far apart or more get no distance points
+	maxDistanceKm = 200.0
+	// below this age cats are too young to breed
+	breedingMinAge = 12
+)
+
+// breedGroups puts breeds of similar build and coat together, pairings within a group rate higher.
+var breedGroups = map[cat.CatRace]string{
+	cat.Persian:          "longhair",
+	cat.MaineCoon:        "longhair",
+	cat.Ragdoll:          "longhair",
+	cat.Birman:           "longhair",
+	cat.BritishShorthair: "shorthair",
+	cat.ScottishFold:     "shorthair",
+	cat.Siamese:          "oriental",
+	cat.Abyssinian:       "oriental",
+	cat.Bengal:           "oriental",
+	cat.Sphynx:           "hairless",
+}
+
+// BreedFactor rates same breeds highest, then breeds of the same group. Two Scottish Folds get nothing,
+// fold to fold pairings pass on painful bone and cartilage defects.
+func BreedFactor(target *cat.Cat, candidate Candidate) Factor {
+	f := Factor{Name: FactorBreed}
+	switch {
+	case target.Race == cat.ScottishFold && candidate.Cat.Race == cat.ScottishFold:
+		f.Reason = "fold to fold pairings risk bone and cartilage defects"
+	case target.Race == candidate.Cat.Race:
+		f.Value = 1
+		f.Reason = fmt.Sprintf("both are %s", target.Race)
+	case breedGroups[target.Race] == breedGroups[candidate.Cat.Race]:
+		f.Value = 0.6
+		f.Reason = fmt.Sprintf("%s and %s are both %s breeds", target.Race, candidate.Cat.Race, breedGroups[target.Race])
+	default:
+		f.Value = 0.3
+		f.Reason = fmt.Sprintf("%s and %s are different breeds", target.Race, candidate.Cat.Race)
+	}
+	return f
+}
+
+// AgeFactor rates cats of similar age higher, and gives nothing to cats too young to breed.
+func AgeFactor(target *cat.Cat, candidate Candidate) Factor {
+	f := Factor{Name: FactorAge}
+	if candidate.Cat.Age < breedingMinAge {
+		f.Reason = fmt.Sprintf("%d months old, too young to breed", candidate.Cat.Age)
+		return f
+	}
+	gap := int(math.Abs(float64(target.Age - candidate.Cat.Age)))
+	f.Value = math.Max(0, 1-float64(gap)/maxAgeGap)
+	if gap == 0 {
+		f.Reason = "same age"
+	} else {
+		f.Reason = fmt.Sprintf("%d months apart", gap)
+	}
+	return f
+}
+
+// DistanceFactor rates closer cats higher. An unknown distance rates halfway.
+func DistanceFactor(target *cat.Cat, candidate Candidate) Factor {
+	f := Factor{Name: FactorDistance}
+	if candidate.DistanceKm == nil {
+		f.Value = 0.5
+		f.Reason = "distance unknown"
+		return f
+	}
+	f.Value = math.Max(0, 1-*candidate.DistanceKm/maxDistanceKm)
+	f.Reason = fmt.Sprintf("about %.0f km away", geo.ApproximateKm(*candidate.DistanceKm))
+	return f
+}
+
+// HealthFactor rates the candidate's health from its curated tags: vaccinated cats rate higher, cats with
+// special needs lower and neutered cats get nothing.
+func HealthFactor(target *cat.Cat, candidate Candidate) Factor {
+	f := Factor{Name: FactorHealth, Value: 0.5, Reason: "no health tags"}
+	tags := candidate.Cat.Tags
+	if slices.Contains(tags, "neutered") {
+		return Factor{Name: FactorHealth, Reason: "neutered"}
+	}
+	if slices.Contains(tags, "vaccinated") {
+		f.Value += 0.5
+		f.Reason = "vaccinated"
+	}
+	if slices.Contains(tags, "special-needs") {
+		f.Value -= 0.25
+		if f.Reason == "vaccinated" {
+			f.Reason = "vaccinated, has special needs"
+		} else {
+			f.Reason = "has special needs"
+		}
+	}
+	return f
+}
+
+// AcceptanceFactor rates owners by the share of received requests they approved. Owners who never
+// answered a request rate halfway.
+func AcceptanceFactor(target *cat.Cat, candidate Candidate) Factor {
+	f := Factor{Name: FactorAcceptance}
+	a := candidate.Acceptance
+	if a.Responded == 0 {
+		f.Value = 0.5
+		f.Reason = "owner has not answered any requests yet"
+		return f
+	}
+	f.Value = float64(a.Approved) / float64(a.Responded)
+	f.Reason = fmt.Sprintf("owner approved %d of %d requests", a.Approved, a.Responded)
+	return f
+}
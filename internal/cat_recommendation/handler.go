@@ -0,0 +1,80 @@
+package catrecommendation
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/citadel-corp/cats-social/internal/cat"
+	"github.com/citadel-corp/cats-social/internal/common/middleware"
+	"github.com/citadel-corp/cats-social/internal/common/response"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/schema"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetRecommendationList(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{})
+		return
+	}
+
+	newSchema := schema.NewDecoder()
+	newSchema.IgnoreUnknownKeys(true)
+
+	var req ListRecommendationPayload
+	if err = newSchema.Decode(&req, r.URL.Query()); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
+			Message: "Bad request",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	params := mux.Vars(r)
+	id := params["id"]
+	recommendations, err := h.service.List(r.Context(), req, id, userID)
+	if errors.Is(err, ErrValidationFailed) || errors.Is(err, cat.ErrCatHasMatched) {
+		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
+			Message: "Bad request",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, cat.ErrCatNotFound) {
+		response.JSON(w, http.StatusNotFound, response.ResponseBody{
+			Message: "Not found",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
+			Message: "Internal server error",
+			Error:   err.Error(),
+		})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.ResponseBody{
+		Message: "success",
+		Data:    recommendations,
+	})
+}
+
+func getUserID(r *http.Request) (int64, error) {
+	if authValue, ok := r.Context().Value(middleware.ContextAuthKey{}).(string); ok {
+		return strconv.ParseInt(authValue, 10, 64)
+	} else {
+		slog.Error("cannot parse auth value from context")
+		return 0, errors.New("cannot parse auth value from context")
+	}
+}
@@ -0,0 +1,94 @@
+package catrecommendation
+
+import (
+	"math"
+
+	"github.com/citadel-corp/cats-social/internal/cat"
+)
+
+// Candidate is a cat that could be matched with the cat recommendations are made for.
+type Candidate struct {
+	Cat *cat.Cat
+	// DistanceKm is unknown when either cat has no location
+	DistanceKm *float64
+	Acceptance Acceptance
+}
+
+// Acceptance is how the candidate's owner answered the match requests they received.
+type Acceptance struct {
+	Approved  int
+	Responded int
+}
+
+// Factor is one part of a compatibility score, rated from 0 to 1 with the reason for the rating.
+type Factor struct {
+	Name   string
+	Value  float64
+	Reason string
+}
+
+// FactorFunc rates a single aspect of how compatible candidate is with target.
+type FactorFunc func(target *cat.Cat, candidate Candidate) Factor
+
+// Score is a candidate's compatibility from 0 to 100, with the weighted factors it is made of.
+type Score struct {
+	Total   float64
+	Factors []WeightedFactor
+}
+
+type WeightedFactor struct {
+	Factor
+	Weight float64
+	// Points is what the factor contributes to the total
+	Points float64
+}
+
+// Scorer ranks candidates, higher scores are recommended first. Any ranking can be plugged into the
+// service by implementing it.
+type Scorer interface {
+	Score(target *cat.Cat, candidate Candidate) Score
+}
+
+// Weighted is a factor with its share of a WeightedScorer's total.
+type Weighted struct {
+	Weight float64
+	Factor FactorFunc
+}
+
+// WeightedScorer scores candidates as the weighted average of its factors.
+type WeightedScorer []Weighted
+
+// DefaultScorer favors cats of compatible breeds and similar age nearby, in good health, whose owners
+// tend to accept requests.
+var DefaultScorer Scorer = WeightedScorer{
+	{Weight: 30, Factor: BreedFactor},
+	{Weight: 25, Factor: AgeFactor},
+	{Weight: 20, Factor: DistanceFactor},
+	{Weight: 15, Factor: HealthFactor},
+	{Weight: 10, Factor: AcceptanceFactor},
+}
+
+// Score implements Scorer.
+func (s WeightedScorer) Score(target *cat.Cat, candidate Candidate) Score {
+	totalWeight := 0.0
+	for _, w := range s {
+		totalWeight += w.Weight
+	}
+	res := Score{Factors: make([]WeightedFactor, len(s))}
+	if totalWeight <= 0 {
+		return res
+	}
+	for i, w := range s {
+		factor := w.Factor(target, candidate)
+		points := round(100 * factor.Value * w.Weight / totalWeight)
+		res.Factors[i] = WeightedFactor{Factor: factor, Weight: w.Weight, Points: points}
+		res.Total += points
+	}
+	res.Total = round(res.Total)
+	return res
+}
+
+// round keeps one decimal.
+func round(f float64) float64 {
+	return math.Round(f*10) / 10
+}
@@ -0,0 +1,73 @@
+package catrecommendation
+
+import (
+	"context"
+
+	catmatch "github.com/citadel-corp/cats-social/internal/cat_match"
+	"github.com/citadel-corp/cats-social/internal/common/db"
+	"github.com/lib/pq"
+)
+
+type Repository interface {
+	ListRequestedCatIDs(ctx context.Context, catID int64) ([]int64, error)
+	GetAcceptance(ctx context.Context, userIDs []int64) (map[int64]Acceptance, error)
+}
+
+type dbRepository struct {
+	db *db.DB
+}
+
+func NewRepository(db *db.DB) Repository {
+	return &dbRepository{db: db}
+}
+
+// ListRequestedCatIDs implements Repository. Lists the cats with a pending request to or from the cat.
+func (d *dbRepository) ListRequestedCatIDs(ctx context.Context, catID int64) ([]int64, error) {
+	listQuery := `
+		SELECT CASE WHEN issuer_cat_id = $1 THEN matched_cat_id ELSE issuer_cat_id END
+		FROM cat_matches
+		WHERE (issuer_cat_id = $1 OR matched_cat_id = $1) AND approval_status = $2;
+	`
+	rows, err := d.db.DB().QueryContext(ctx, listQuery, catID, catmatch.Pending)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		res = append(res, id)
+	}
+	return res, rows.Err()
+}
+
+// GetAcceptance implements Repository. Owners who never answered a request are left out. Dissolved matches
+// were approved before they ended, so they count as approved.
+func (d *dbRepository) GetAcceptance(ctx context.Context, userIDs []int64) (map[int64]Acceptance, error) {
+	acceptanceQuery := `
+		SELECT matched_user_id,
+		COUNT(*) FILTER (WHERE approval_status IN ($2, $3)),
+		COUNT(*)
+		FROM cat_matches
+		WHERE matched_user_id = ANY($1) AND approval_status IN ($2, $3, $4)
+		GROUP BY matched_user_id;
+	`
+	rows, err := d.db.DB().QueryContext(ctx, acceptanceQuery, pq.Array(userIDs), catmatch.Approved, catmatch.Dissolved, catmatch.Rejected)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make(map[int64]Acceptance)
+	for rows.Next() {
+		var userID int64
+		a := Acceptance{}
+		if err = rows.Scan(&userID, &a.Approved, &a.Responded); err != nil {
+			return nil, err
+		}
+		res[userID] = a
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,15 @@
+package catrecommendation
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+type ListRecommendationPayload struct {
+	Limit int `schema:"limit" binding:"omitempty"`
+}
+
+func (p ListRecommendationPayload) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Limit, validation.Min(0), validation.Max(50)),
+	)
+}
@@ -0,0 +1,69 @@
+package catrecommendation
+
+import (
+	"math"
+
+	"github.com/citadel-corp/cats-social/internal/cat"
+	"github.com/citadel-corp/cats-social/internal/common/geo"
+)
+
+type RecommendationResponse struct {
+	Cat       cat.CatResponse  `json:"cat"`
+	Score     float64          `json:"score"`
+	Breakdown []FactorResponse `json:"breakdown"`
+}
+
+type FactorResponse struct {
+	Factor string  `json:"factor"`
+	Weight float64 `json:"weight"`
+	Value  float64 `json:"value"`
+	Points float64 `json:"points"`
+	Reason string  `json:"reason"`
+}
+
+func makeRecommendationResponse(candidate Candidate, score Score) RecommendationResponse {
+	c := candidate.Cat
+	res := RecommendationResponse{
+		Cat: cat.CatResponse{
+			ID:          c.UID,
+			Name:        c.Name,
+			Race:        string(c.Race),
+			Sex:         string(c.Sex),
+			AgeInMonth:  c.Age,
+			ImageUrls:   c.ImageURLS,
+			Tags:        c.Tags,
+			Description: c.Description,
+			HasMatched:  c.HasMatched,
+			Visibility:  string(c.Visibility),
+			Version:     c.Version,
+			CreatedAt:   c.CreatedAt,
+
+			IsFavorited:   c.IsFavorited,
+			FavoriteCount: c.FavoriteCount,
+		},
+		Score:     score.Total,
+		Breakdown: make([]FactorResponse, len(score.Factors)),
+	}
+	for _, image := range c.Images {
+		if image.IsPrimary {
+			res.Cat.CoverURL = image.URL
+		}
+	}
+	if c.Location != nil {
+		res.Cat.City = c.Location.City
+	}
+	if candidate.DistanceKm != nil {
+		distance := geo.ApproximateKm(*candidate.DistanceKm)
+		res.Cat.DistanceKm = &distance
+	}
+	for i, f := range score.Factors {
+		res.Breakdown[i] = FactorResponse{
+			Factor: f.Name,
+			Weight: f.Weight,
+			Value:  math.Round(f.Value*100) / 100,
+			Points: f.Points,
+			Reason: f.Reason,
+		}
+	}
+	return res
+}
@@ -0,0 +1,133 @@
+package catrecommendation
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/citadel-corp/cats-social/internal/cat"
+)
+
+const (
+	defaultListLimit = 10
+	// candidatePool is how many candidates are scored, the nearest ones when the cat has a location
+	candidatePool = 500
+)
+
+type Service interface {
+	List(ctx context.Context, req ListRecommendationPayload, catID string, userID int64) ([]RecommendationResponse, error)
+	// Rank scores the candidates for target, leaving out the cats in exclude, and returns the best limit ones
+	Rank(ctx context.Context, target *cat.Cat, userID int64, exclude []int64, limit int) ([]RecommendationResponse, error)
+}
+
+type catRecommendationService struct {
+	repository    Repository
+	catRepository cat.Repository
+	scorer        Scorer
+}
+
+// NewService creates the recommendation Service ranking candidates with scorer, DefaultScorer when nil.
+func NewService(repository Repository, catRepository cat.Repository, scorer Scorer) Service {
+	if scorer == nil {
+		scorer = DefaultScorer
+	}
+	return &catRecommendationService{repository: repository, catRepository: catRepository, scorer: scorer}
+}
+
+// List implements Service.
+func (s *catRecommendationService) List(ctx context.Context, req ListRecommendationPayload, catID string, userID int64) ([]RecommendationResponse, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrValidationFailed, err)
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultListLimit
+	}
+	target, err := s.catRepository.GetByUIDAndUserID(ctx, catID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if target.HasMatched {
+		return nil, cat.ErrCatHasMatched
+	}
+	return s.Rank(ctx, target, userID, nil, req.Limit)
+}
+
+// Rank implements Service. Candidates are public cats of the opposite sex that haven't matched, aren't
+// owned by the user and have no pending request with the cat, best scores first.
+func (s *catRecommendationService) Rank(ctx context.Context, target *cat.Cat, userID int64, exclude []int64, limit int) ([]RecommendationResponse, error) {
+	requested, err := s.repository.ListRequestedCatIDs(ctx, target.ID)
+	if err != nil {
+		return nil, err
+	}
+	// excluded in the query, so the pool is made of candidates only however many cats were left out
+	listReq := cat.ListCatPayload{
+		Sex:            string(oppositeSex(target.Sex)),
+		Limit:          candidatePool,
+		AgeSearchType:  cat.IgnoreAge,
+		HasMatchedType: cat.HasNotMatched,
+		NotOwned:       true,
+		ExcludeIDs:     append(requested, exclude...),
+	}
+	origin := target.Location
+	if origin == nil {
+		origin, err = s.catRepository.GetOwnerLocation(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if origin != nil {
+		listReq.Origin = &origin.Point
+		listReq.Sort = cat.SortDistance
+	}
+	cats, err := s.catRepository.List(ctx, listReq, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]Candidate, 0, len(cats))
+	ownerIDs := make([]int64, 0, len(cats))
+	for _, c := range cats {
+		candidates = append(candidates, Candidate{Cat: c, DistanceKm: c.DistanceKm})
+		ownerIDs = append(ownerIDs, c.UserID)
+	}
+	acceptance, err := s.repository.GetAcceptance(ctx, ownerIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	type scored struct {
+		candidate Candidate
+		score     Score
+	}
+	ranked := make([]scored, len(candidates))
+	for i, candidate := range candidates {
+		candidate.Acceptance = acceptance[candidate.Cat.UserID]
+		ranked[i] = scored{candidate: candidate, score: s.scorer.Score(target, candidate)}
+	}
+	// stable, so equal scores keep the nearest first
+	slices.SortStableFunc(ranked, func(a, b scored) int {
+		switch {
+		case a.score.Total > b.score.Total:
+			return -1
+		case a.score.Total < b.score.Total:
+			return 1
+		}
+		return 0
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	res := make([]RecommendationResponse, len(ranked))
+	for i, r := range ranked {
+		res[i] = makeRecommendationResponse(r.candidate, r.score)
+	}
+	return res, nil
+}
+
+func oppositeSex(sex cat.CatSex) cat.CatSex {
+	if sex == cat.Male {
+		return cat.Female
+	}
+	return cat.Male
+}
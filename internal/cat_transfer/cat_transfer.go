@@ -0,0 +1,32 @@
+package cattransfer
+
+import "time"
+
+type TransferStatus string
+
+const (
+	Pending   TransferStatus = "pending"
+	Accepted  TransferStatus = "accepted"
+	Declined  TransferStatus = "declined"
+	Cancelled TransferStatus = "cancelled"
+)
+
+type CatTransfer struct {
+	ID          int64
+	UID         string
+	CatID       int64
+	FromUserID  int64
+	ToUserID    int64
+	Status      TransferStatus
+	CreatedAt   time.Time
+	RespondedAt *time.Time
+}
+
+// CatTransferList is a transfer joined with its cat and both owners.
+type CatTransferList struct {
+	CatTransfer
+	CatUID   string
+	CatName  string
+	FromUser Owner
+	ToUser   Owner
+}
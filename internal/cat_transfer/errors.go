@@ -0,0 +1,12 @@
+package cattransfer
+
+import "errors"
+
+var (
+	ErrTransferNotFound       = errors.New("cat transfer not found")
+	ErrTransferNoLongerValid  = errors.New("cat transfer no longer valid")
+	ErrTransferAlreadyPending = errors.New("cat already has a pending transfer")
+	ErrTransferToSelf         = errors.New("cannot transfer a cat to yourself")
+	ErrTransferForbidden      = errors.New("not allowed to change this transfer")
+	ErrValidationFailed       = errors.New("validation failed")
+)
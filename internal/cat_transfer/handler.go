@@ -0,0 +1,209 @@
+package cattransfer
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/citadel-corp/cats-social/internal/cat"
+	"github.com/citadel-corp/cats-social/internal/common/middleware"
+	"github.com/citadel-corp/cats-social/internal/common/request"
+	"github.com/citadel-corp/cats-social/internal/common/response"
+	"github.com/citadel-corp/cats-social/internal/user"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/schema"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{})
+		return
+	}
+
+	var req CreateTransferPayload
+
+	err = request.DecodeJSON(w, r, &req)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
+			Message: "Failed to decode JSON",
+			Error:   err.Error(),
+		})
+		return
+	}
+	params := mux.Vars(r)
+	id := params["id"]
+	transfer, err := h.service.Create(r.Context(), req, id, userID)
+	if errors.Is(err, ErrValidationFailed) || errors.Is(err, ErrTransferToSelf) {
+		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
+			Message: "Bad request",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, cat.ErrCatNotFound) || errors.Is(err, user.ErrUserNotFound) {
+		response.JSON(w, http.StatusNotFound, response.ResponseBody{
+			Message: "Not found",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, ErrTransferAlreadyPending) {
+		response.JSON(w, http.StatusConflict, response.ResponseBody{
+			Message: "Conflict",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
+			Message: "Internal server error",
+			Error:   err.Error(),
+		})
+		return
+	}
+	response.JSON(w, http.StatusCreated, response.ResponseBody{
+		Message: "success",
+		Data:    transfer,
+	})
+}
+
+func (h *Handler) GetTransferList(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{})
+		return
+	}
+
+	newSchema := schema.NewDecoder()
+	newSchema.IgnoreUnknownKeys(true)
+
+	var req ListTransferPayload
+	if err = newSchema.Decode(&req, r.URL.Query()); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.ResponseBody{})
+		return
+	}
+
+	transfers, err := h.service.List(r.Context(), req, userID)
+	if errors.Is(err, ErrValidationFailed) {
+		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
+			Message: "Bad request",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
+			Message: "Internal server error",
+			Error:   err.Error(),
+		})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.ResponseBody{
+		Message: "success",
+		Data:    transfers,
+	})
+}
+
+func (h *Handler) Accept(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, h.service.Accept)
+}
+
+func (h *Handler) Decline(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, h.service.Decline)
+}
+
+func (h *Handler) Cancel(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, h.service.Cancel)
+}
+
+func (h *Handler) GetOwnershipHistory(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{})
+		return
+	}
+	params := mux.Vars(r)
+	id := params["id"]
+	history, err := h.service.History(r.Context(), id, userID)
+	if errors.Is(err, cat.ErrCatNotFound) {
+		response.JSON(w, http.StatusNotFound, response.ResponseBody{
+			Message: "Not found",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
+			Message: "Internal server error",
+			Error:   err.Error(),
+		})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.ResponseBody{
+		Message: "success",
+		Data:    history,
+	})
+}
+
+// respond runs a status change on the transfer in the path and maps its errors.
+func (h *Handler) respond(w http.ResponseWriter, r *http.Request, change func(ctx context.Context, id string, userID int64) error) {
+	userID, err := getUserID(r)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{})
+		return
+	}
+	params := mux.Vars(r)
+	id := params["id"]
+	err = change(r.Context(), id, userID)
+	if errors.Is(err, ErrTransferNoLongerValid) {
+		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
+			Message: "Bad request",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, ErrTransferForbidden) {
+		response.JSON(w, http.StatusForbidden, response.ResponseBody{
+			Message: "Forbidden",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, ErrTransferNotFound) {
+		response.JSON(w, http.StatusNotFound, response.ResponseBody{
+			Message: "Not found",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
+			Message: "Internal server error",
+			Error:   err.Error(),
+		})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.ResponseBody{
+		Message: "success",
+	})
+}
+
+func getUserID(r *http.Request) (int64, error) {
+	if authValue, ok := r.Context().Value(middleware.ContextAuthKey{}).(string); ok {
+		return strconv.ParseInt(authValue, 10, 64)
+	} else {
+		slog.Error("cannot parse auth value from context")
+		return 0, errors.New("cannot parse auth value from context")
+	}
+}
@@ -0,0 +1,263 @@
+package cattransfer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/citadel-corp/cats-social/internal/cat"
+	catmatch "github.com/citadel-corp/cats-social/internal/cat_match"
+	"github.com/citadel-corp/cats-social/internal/common/db"
+	"github.com/citadel-corp/cats-social/internal/outbox"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type Repository interface {
+	Create(ctx context.Context, transfer *CatTransfer) error
+	GetByUID(ctx context.Context, uid string) (*CatTransfer, error)
+	GetListByUID(ctx context.Context, uid string) (*CatTransferList, error)
+	List(ctx context.Context, req ListTransferPayload, userID int64) ([]CatTransferList, error)
+	ListAcceptedByCatID(ctx context.Context, catID int64) ([]CatTransferList, error)
+	Accept(ctx context.Context, transfer *CatTransfer) error
+	UpdateStatus(ctx context.Context, transfer *CatTransfer, status TransferStatus) error
+}
+
+type dbRepository struct {
+	db *db.DB
+}
+
+func NewRepository(db *db.DB) Repository {
+	return &dbRepository{db: db}
+}
+
+const listTransferQuery = `
+	SELECT t.id, t.uid, t.cat_id, t.from_user_id, t.to_user_id, t.status, t.created_at, t.responded_at,
+	c.uid, c.name, fu.name, fu.email, tu.name, tu.email
+	FROM cat_transfers t
+	JOIN cats c on t.cat_id = c.id
+	JOIN users fu on t.from_user_id = fu.id
+	JOIN users tu on t.to_user_id = tu.id
+`
+
+// Create implements Repository.
+func (d *dbRepository) Create(ctx context.Context, transfer *CatTransfer) error {
+	createTransferQuery := `
+		INSERT INTO cat_transfers (
+			uid, cat_id, from_user_id, to_user_id
+		) VALUES (
+			$1, $2, $3, $4
+		) RETURNING id, status, created_at;
+	`
+	row := d.db.DB().QueryRowContext(ctx, createTransferQuery, transfer.UID, transfer.CatID, transfer.FromUserID, transfer.ToUserID)
+	err := row.Scan(&transfer.ID, &transfer.Status, &transfer.CreatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrTransferAlreadyPending
+	}
+	return err
+}
+
+// GetByUID implements Repository.
+func (d *dbRepository) GetByUID(ctx context.Context, uid string) (*CatTransfer, error) {
+	getTransferQuery := `
+		SELECT id, uid, cat_id, from_user_id, to_user_id, status, created_at, responded_at
+		FROM cat_transfers
+		WHERE uid = $1;
+	`
+	row := d.db.DB().QueryRowContext(ctx, getTransferQuery, uid)
+	t := &CatTransfer{}
+	err := row.Scan(&t.ID, &t.UID, &t.CatID, &t.FromUserID, &t.ToUserID, &t.Status, &t.CreatedAt, &t.RespondedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTransferNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// GetListByUID implements Repository.
+func (d *dbRepository) GetListByUID(ctx context.Context, uid string) (*CatTransferList, error) {
+	rows, err := d.db.DB().QueryContext(ctx, listTransferQuery+"WHERE t.uid = $1;", uid)
+	if err != nil {
+		return nil, err
+	}
+	res, err := scanTransferList(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, ErrTransferNotFound
+	}
+	return &res[0], nil
+}
+
+// List implements Repository.
+func (d *dbRepository) List(ctx context.Context, req ListTransferPayload, userID int64) ([]CatTransferList, error) {
+	listQuery := listTransferQuery + "WHERE "
+	params := []interface{}{userID}
+	switch req.Direction {
+	case DirectionIncoming:
+		listQuery += "t.to_user_id = $1 "
+	case DirectionOutgoing:
+		listQuery += "t.from_user_id = $1 "
+	default:
+		listQuery += "(t.from_user_id = $1 OR t.to_user_id = $1) "
+	}
+	if req.Status != "" {
+		listQuery += fmt.Sprintf("AND t.status = $%d ", len(params)+1)
+		params = append(params, req.Status)
+	}
+	listQuery += "ORDER BY t.created_at DESC;"
+	rows, err := d.db.DB().QueryContext(ctx, listQuery, params...)
+	if err != nil {
+		return nil, err
+	}
+	return scanTransferList(rows)
+}
+
+// ListAcceptedByCatID implements Repository.
+func (d *dbRepository) ListAcceptedByCatID(ctx context.Context, catID int64) ([]CatTransferList, error) {
+	listQuery := listTransferQuery + `
+		WHERE t.cat_id = $1 AND t.status = $2
+		ORDER BY t.responded_at ASC;
+	`
+	rows, err := d.db.DB().QueryContext(ctx, listQuery, catID, Accepted)
+	if err != nil {
+		return nil, err
+	}
+	return scanTransferList(rows)
+}
+
+// Accept implements Repository. The cat changes hands and its pending matches are cancelled in one transaction,
+// along with their domain events.
+func (d *dbRepository) Accept(ctx context.Context, transfer *CatTransfer) error {
+	return d.db.StartTx(ctx, func(tx *sql.Tx) error {
+		lockTransferQuery := `
+			SELECT status
+			FROM cat_transfers
+			WHERE id = $1
+			FOR UPDATE;
+		`
+		var status TransferStatus
+		err := tx.QueryRowContext(ctx, lockTransferQuery, transfer.ID).Scan(&status)
+		if err != nil {
+			return err
+		}
+		if status != Pending {
+			return ErrTransferNoLongerValid
+		}
+
+		// the cat may have been deleted or moved since the transfer started
+		lockCatQuery := `
+			SELECT user_id, uid, name, version
+			FROM cats
+			WHERE id = $1
+			FOR UPDATE;
+		`
+		var ownerID int64
+		c := cat.OutboxEvent{UserID: transfer.ToUserID, ActorUserID: transfer.ToUserID}
+		err = tx.QueryRowContext(ctx, lockCatQuery, transfer.CatID).Scan(&ownerID, &c.ID, &c.Name, &c.Version)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTransferNoLongerValid
+		}
+		if err != nil {
+			return err
+		}
+		if ownerID != transfer.FromUserID {
+			return ErrTransferNoLongerValid
+		}
+
+		updateCatQuery := `
+			UPDATE cats
+			SET user_id = $1
+			WHERE id = $2;
+		`
+		_, err = tx.ExecContext(ctx, updateCatQuery, transfer.ToUserID, transfer.CatID)
+		if err != nil {
+			return err
+		}
+		err = outbox.Record(ctx, tx, cat.OutboxAggregate, c.ID, outbox.CatTransferred, c)
+		if err != nil {
+			return err
+		}
+
+		from, to := catmatch.SystemTransition(catmatch.ActionCancel)
+		cancelMatchesQuery := `
+			UPDATE cat_matches
+			SET approval_status = $1
+			WHERE (issuer_cat_id = $2 OR matched_cat_id = $2) AND approval_status = $3
+			RETURNING uid, issuer_cat_id, issuer_user_id, matched_cat_id, matched_user_id;
+		`
+		rows, err := tx.QueryContext(ctx, cancelMatchesQuery, to, transfer.CatID, from)
+		if err != nil {
+			return err
+		}
+		cancelled := make([]catmatch.CatMatches, 0)
+		for rows.Next() {
+			m := catmatch.CatMatches{}
+			if err = rows.Scan(&m.UID, &m.IssuerCatId, &m.IssueUserId, &m.MatchCatId, &m.MatchUserId); err != nil {
+				rows.Close()
+				return err
+			}
+			cancelled = append(cancelled, m)
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return err
+		}
+		for _, m := range cancelled {
+			err = catmatch.RecordEvent(ctx, tx, m, to, transfer.ToUserID)
+			if err != nil {
+				return err
+			}
+		}
+
+		updateTransferQuery := `
+			UPDATE cat_transfers
+			SET status = $1,
+			responded_at = current_timestamp
+			WHERE id = $2;
+		`
+		_, err = tx.ExecContext(ctx, updateTransferQuery, Accepted, transfer.ID)
+		return err
+	})
+}
+
+// UpdateStatus implements Repository. Only pending transfers can change status.
+func (d *dbRepository) UpdateStatus(ctx context.Context, transfer *CatTransfer, status TransferStatus) error {
+	updateTransferQuery := `
+		UPDATE cat_transfers
+		SET status = $1,
+		responded_at = current_timestamp
+		WHERE id = $2 AND status = $3;
+	`
+	row, err := d.db.DB().ExecContext(ctx, updateTransferQuery, status, transfer.ID, Pending)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := row.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrTransferNoLongerValid
+	}
+	return nil
+}
+
+func scanTransferList(rows *sql.Rows) ([]CatTransferList, error) {
+	defer rows.Close()
+	res := make([]CatTransferList, 0)
+	for rows.Next() {
+		t := CatTransferList{}
+		err := rows.Scan(&t.ID, &t.UID, &t.CatID, &t.FromUserID, &t.ToUserID, &t.Status, &t.CreatedAt, &t.RespondedAt,
+			&t.CatUID, &t.CatName, &t.FromUser.Name, &t.FromUser.Email, &t.ToUser.Name, &t.ToUser.Email)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, t)
+	}
+	return res, rows.Err()
+}
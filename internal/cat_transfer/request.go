@@ -0,0 +1,33 @@
+package cattransfer
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+)
+
+const (
+	DirectionIncoming = "incoming"
+	DirectionOutgoing = "outgoing"
+)
+
+type CreateTransferPayload struct {
+	Email string `json:"email"`
+}
+
+func (p CreateTransferPayload) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Email, validation.Required, is.EmailFormat),
+	)
+}
+
+type ListTransferPayload struct {
+	Direction string `schema:"direction" binding:"omitempty"`
+	Status    string `schema:"status" binding:"omitempty"`
+}
+
+func (p ListTransferPayload) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Direction, validation.In(DirectionIncoming, DirectionOutgoing)),
+		validation.Field(&p.Status, validation.In(string(Pending), string(Accepted), string(Declined), string(Cancelled))),
+	)
+}
@@ -0,0 +1,42 @@
+package cattransfer
+
+import "time"
+
+type Owner struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type TransferCat struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type TransferResponse struct {
+	ID          string      `json:"id"`
+	Cat         TransferCat `json:"cat"`
+	From        Owner       `json:"from"`
+	To          Owner       `json:"to"`
+	Status      string      `json:"status"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	RespondedAt *time.Time  `json:"respondedAt,omitempty"`
+}
+
+type OwnershipResponse struct {
+	OwnerName  string     `json:"ownerName"`
+	Since      time.Time  `json:"since"`
+	Until      *time.Time `json:"until,omitempty"`
+	TransferID string     `json:"transferId,omitempty"`
+}
+
+func makeTransferResponse(t CatTransferList) TransferResponse {
+	return TransferResponse{
+		ID:          t.UID,
+		Cat:         TransferCat{ID: t.CatUID, Name: t.CatName},
+		From:        t.FromUser,
+		To:          t.ToUser,
+		Status:      string(t.Status),
+		CreatedAt:   t.CreatedAt,
+		RespondedAt: t.RespondedAt,
+	}
+}
@@ -0,0 +1,164 @@
+package cattransfer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/citadel-corp/cats-social/internal/cat"
+	"github.com/citadel-corp/cats-social/internal/common/id"
+	"github.com/citadel-corp/cats-social/internal/user"
+)
+
+type Service interface {
+	Create(ctx context.Context, req CreateTransferPayload, catID string, userID int64) (*TransferResponse, error)
+	List(ctx context.Context, req ListTransferPayload, userID int64) ([]TransferResponse, error)
+	Accept(ctx context.Context, id string, userID int64) error
+	Decline(ctx context.Context, id string, userID int64) error
+	Cancel(ctx context.Context, id string, userID int64) error
+	History(ctx context.Context, catID string, userID int64) ([]OwnershipResponse, error)
+}
+
+type catTransferService struct {
+	repository     Repository
+	catRepository  cat.Repository
+	userRepository user.Repository
+}
+
+func NewService(repository Repository, catRepository cat.Repository, userRepository user.Repository) Service {
+	return &catTransferService{repository: repository, catRepository: catRepository, userRepository: userRepository}
+}
+
+// Create implements Service.
+func (s *catTransferService) Create(ctx context.Context, req CreateTransferPayload, catID string, userID int64) (*TransferResponse, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrValidationFailed, err)
+	}
+
+	c, err := s.catRepository.GetByUIDAndUserID(ctx, catID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	recipient, err := s.userRepository.GetByEmail(ctx, req.Email)
+	if err != nil {
+		return nil, err
+	}
+	if recipient.ID == userID {
+		return nil, ErrTransferToSelf
+	}
+
+	transfer := &CatTransfer{
+		UID:        id.GenerateStringID(16),
+		CatID:      c.ID,
+		FromUserID: userID,
+		ToUserID:   recipient.ID,
+	}
+	err = s.repository.Create(ctx, transfer)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := s.repository.GetListByUID(ctx, transfer.UID)
+	if err != nil {
+		return nil, err
+	}
+	res := makeTransferResponse(*t)
+	return &res, nil
+}
+
+// List implements Service.
+func (s *catTransferService) List(ctx context.Context, req ListTransferPayload, userID int64) ([]TransferResponse, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrValidationFailed, err)
+	}
+	transfers, err := s.repository.List(ctx, req, userID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]TransferResponse, len(transfers))
+	for i, t := range transfers {
+		res[i] = makeTransferResponse(t)
+	}
+	return res, nil
+}
+
+// Accept implements Service. Only the recipient can accept.
+func (s *catTransferService) Accept(ctx context.Context, id string, userID int64) error {
+	transfer, err := s.getParticipating(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if transfer.ToUserID != userID {
+		return ErrTransferForbidden
+	}
+	if transfer.Status != Pending {
+		return ErrTransferNoLongerValid
+	}
+	return s.repository.Accept(ctx, transfer)
+}
+
+// Decline implements Service. Only the recipient can decline.
+func (s *catTransferService) Decline(ctx context.Context, id string, userID int64) error {
+	transfer, err := s.getParticipating(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if transfer.ToUserID != userID {
+		return ErrTransferForbidden
+	}
+	return s.repository.UpdateStatus(ctx, transfer, Declined)
+}
+
+// Cancel implements Service. Only the current owner who started the transfer can cancel it.
+func (s *catTransferService) Cancel(ctx context.Context, id string, userID int64) error {
+	transfer, err := s.getParticipating(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if transfer.FromUserID != userID {
+		return ErrTransferForbidden
+	}
+	return s.repository.UpdateStatus(ctx, transfer, Cancelled)
+}
+
+// History implements Service. Ownership periods are derived from the cat's accepted transfers.
+func (s *catTransferService) History(ctx context.Context, catID string, userID int64) ([]OwnershipResponse, error) {
+	c, err := s.catRepository.GetByUID(ctx, catID, userID)
+	if err != nil {
+		return nil, err
+	}
+	transfers, err := s.repository.ListAcceptedByCatID(ctx, c.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(transfers) == 0 {
+		owner, err := s.userRepository.GetByID(ctx, uint64(c.UserID))
+		if err != nil {
+			return nil, err
+		}
+		return []OwnershipResponse{{OwnerName: owner.Name, Since: c.CreatedAt}}, nil
+	}
+
+	res := make([]OwnershipResponse, 0, len(transfers)+1)
+	res = append(res, OwnershipResponse{OwnerName: transfers[0].FromUser.Name, Since: c.CreatedAt})
+	for _, t := range transfers {
+		res[len(res)-1].Until = t.RespondedAt
+		res = append(res, OwnershipResponse{OwnerName: t.ToUser.Name, Since: *t.RespondedAt, TransferID: t.UID})
+	}
+	return res, nil
+}
+
+// getParticipating hides transfers from users who are neither sender nor recipient.
+func (s *catTransferService) getParticipating(ctx context.Context, id string, userID int64) (*CatTransfer, error) {
+	transfer, err := s.repository.GetByUID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.FromUserID != userID && transfer.ToUserID != userID {
+		return nil, ErrTransferNotFound
+	}
+	return transfer, nil
+}
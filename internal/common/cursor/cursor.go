@@ -0,0 +1,52 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimestampFormat matches the precision of postgres timestamps, used to compare a cursor against created_at.
+const TimestampFormat = "2006-01-02 15:04:05.999999"
+
+var ErrInvalidCursor = errors.New("cursor is not valid")
+
+// Cursor points at the last row of a page for lists ordered newest first, ties broken by id.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// Encode returns the opaque form of c handed to clients.
+func (c Cursor) Encode() string {
+	raw := fmt.Sprintf("%d:%d", c.CreatedAt.UnixNano(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Timestamp returns the cursor time formatted for a TIMESTAMP query parameter.
+func (c Cursor) Timestamp() string {
+	return c.CreatedAt.Format(TimestampFormat)
+}
+
+func Decode(s string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	rowID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &Cursor{CreatedAt: time.Unix(0, n).UTC(), ID: rowID}, nil
+}
@@ -20,6 +20,13 @@ type DB struct {
 	sqlDB *sql.DB
 }
 
+// Querier is satisfied by both *sql.DB and *sql.Tx, so queries can run in or out of a transaction.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
 func Connect(dbURL string) (*DB, error) {
 	db, err := sql.Open("pgx", dbURL)
 	if err != nil {
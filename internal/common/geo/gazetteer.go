@@ -0,0 +1,49 @@
+package geo
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"strconv"
+	"strings"
+)
+
+//go:embed cities.csv
+var citiesCSV string
+
+type City struct {
+	Name    string
+	Country string
+	Point   Point
+}
+
+var cities = loadCities()
+
+func loadCities() map[string]City {
+	records, err := csv.NewReader(strings.NewReader(citiesCSV)).ReadAll()
+	if err != nil {
+		panic(err)
+	}
+	res := make(map[string]City, len(records))
+	for _, record := range records[1:] {
+		lat, err := strconv.ParseFloat(record[2], 64)
+		if err != nil {
+			panic(err)
+		}
+		lng, err := strconv.ParseFloat(record[3], 64)
+		if err != nil {
+			panic(err)
+		}
+		res[strings.ToLower(record[0])] = City{
+			Name:    record[0],
+			Country: record[1],
+			Point:   Point{Lat: lat, Lng: lng},
+		}
+	}
+	return res
+}
+
+// LookupCity finds a city in the bundled gazetteer, ignoring case.
+func LookupCity(name string) (City, bool) {
+	city, ok := cities[strings.ToLower(strings.TrimSpace(name))]
+	return city, ok
+}
@@ -0,0 +1,107 @@
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	earthRadiusKm = 6371.0
+	kmPerDegree   = 111.045
+
+	// coordinates are stored with two decimals, roughly one kilometer of precision
+	coarsePrecision = 100
+)
+
+var ErrInvalidPoint = errors.New("invalid point, expected lat,lng")
+
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+type Location struct {
+	Point
+	City string
+}
+
+func (p Point) Valid() bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180
+}
+
+// ParsePoint parses a "lat,lng" pair as used in query strings.
+func ParsePoint(s string) (Point, error) {
+	latStr, lngStr, ok := strings.Cut(s, ",")
+	if !ok {
+		return Point{}, ErrInvalidPoint
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	if err != nil {
+		return Point{}, ErrInvalidPoint
+	}
+	lng, err := strconv.ParseFloat(strings.TrimSpace(lngStr), 64)
+	if err != nil {
+		return Point{}, ErrInvalidPoint
+	}
+	p := Point{Lat: lat, Lng: lng}
+	if !p.Valid() {
+		return Point{}, ErrInvalidPoint
+	}
+	return p, nil
+}
+
+// Coarse rounds a point so exact coordinates are never stored.
+func Coarse(p Point) Point {
+	return Point{
+		Lat: math.Round(p.Lat*coarsePrecision) / coarsePrecision,
+		Lng: math.Round(p.Lng*coarsePrecision) / coarsePrecision,
+	}
+}
+
+// DistanceKm returns the great-circle distance between two points.
+func DistanceKm(a, b Point) float64 {
+	dLat := radians(b.Lat - a.Lat)
+	dLng := radians(b.Lng - a.Lng)
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(radians(a.Lat))*math.Cos(radians(b.Lat))*math.Pow(math.Sin(dLng/2), 2)
+	return earthRadiusKm * 2 * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// ApproximateKm rounds a distance up to whole kilometers so responses can't be used to triangulate a location.
+func ApproximateKm(km float64) float64 {
+	return math.Max(1, math.Ceil(km))
+}
+
+// BoundingBox returns the box enclosing a circle around origin, used to prefilter rows before the exact distance check.
+// ok is false when the longitude range wraps around, in which case only latitude should be filtered.
+func BoundingBox(origin Point, radiusKm float64) (minLat, maxLat, minLng, maxLng float64, ok bool) {
+	dLat := radiusKm / kmPerDegree
+	minLat = math.Max(-90, origin.Lat-dLat)
+	maxLat = math.Min(90, origin.Lat+dLat)
+	cos := math.Cos(radians(origin.Lat))
+	if cos < 0.01 {
+		return minLat, maxLat, 0, 0, false
+	}
+	dLng := radiusKm / (kmPerDegree * cos)
+	minLng = origin.Lng - dLng
+	maxLng = origin.Lng + dLng
+	if minLng < -180 || maxLng > 180 {
+		return minLat, maxLat, 0, 0, false
+	}
+	return minLat, maxLat, minLng, maxLng, true
+}
+
+// DistanceSQL returns a plain Postgres haversine expression computing the distance in kilometers
+// between the lat/lng columns and the lat/lng placeholders.
+func DistanceSQL(latCol, lngCol, latParam, lngParam string) string {
+	return fmt.Sprintf("(%[1]f * 2 * ASIN(LEAST(1, SQRT("+
+		"POWER(SIN(RADIANS(%[2]s - %[4]s) / 2), 2) + "+
+		"COS(RADIANS(%[4]s)) * COS(RADIANS(%[2]s)) * POWER(SIN(RADIANS(%[3]s - %[5]s) / 2), 2)))))",
+		earthRadiusKm, latCol, lngCol, latParam, lngParam)
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
@@ -0,0 +1,38 @@
+package geo
+
+import (
+	"errors"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// LocationPayload is a location given either as a gazetteer city or as raw coordinates.
+type LocationPayload struct {
+	City      string   `json:"city"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+}
+
+var cityValidationRule = validation.NewStringRule(func(s string) bool {
+	_, ok := LookupCity(s)
+	return ok
+}, "city is not in the gazetteer")
+
+func (p LocationPayload) Validate() error {
+	if p.City == "" && (p.Latitude == nil || p.Longitude == nil) {
+		return errors.New("location requires a city or both latitude and longitude")
+	}
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.City, cityValidationRule),
+		validation.Field(&p.Latitude, validation.Min(-90.0), validation.Max(90.0)),
+		validation.Field(&p.Longitude, validation.Min(-180.0), validation.Max(180.0)),
+	)
+}
+
+// Location resolves the payload into a coarse location. Cities take precedence over coordinates.
+func (p LocationPayload) Location() Location {
+	if city, ok := LookupCity(p.City); ok {
+		return Location{Point: city.Point, City: city.Name}
+	}
+	return Location{Point: Coarse(Point{Lat: *p.Latitude, Lng: *p.Longitude})}
+}
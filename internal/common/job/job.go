@@ -0,0 +1,127 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/citadel-corp/cats-social/internal/common/id"
+)
+
+type Status string
+
+const (
+	Queued    Status = "queued"
+	Running   Status = "running"
+	Succeeded Status = "succeeded"
+	Failed    Status = "failed"
+)
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+)
+
+// finished jobs are kept around this long so their owner can fetch the result
+const retention = time.Hour
+
+type Job struct {
+	ID         string
+	OwnerID    int64
+	Status     Status
+	Result     any
+	Error      string
+	CreatedAt  time.Time
+	FinishedAt *time.Time
+}
+
+// Cleaner can be implemented by job results holding resources, e.g. temporary files,
+// that must be released once the job is pruned.
+type Cleaner interface {
+	Cleanup()
+}
+
+// Registry runs jobs in the background and keeps their state in memory.
+type Registry struct {
+	mu   sync.RWMutex
+	jobs map[string]*Job
+}
+
+func NewRegistry() *Registry {
+	return &Registry{jobs: make(map[string]*Job)}
+}
+
+// Start runs f in a new goroutine and returns a snapshot of the queued job.
+func (r *Registry) Start(ownerID int64, f func(ctx context.Context) (any, error)) Job {
+	r.prune()
+
+	j := &Job{
+		ID:        id.GenerateStringID(16),
+		OwnerID:   ownerID,
+		Status:    Queued,
+		CreatedAt: time.Now(),
+	}
+	r.mu.Lock()
+	r.jobs[j.ID] = j
+	snapshot := *j
+	r.mu.Unlock()
+
+	go r.run(j, f)
+
+	return snapshot
+}
+
+// Get returns a snapshot of a job, only if it belongs to ownerID.
+func (r *Registry) Get(jobID string, ownerID int64) (Job, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	j, ok := r.jobs[jobID]
+	if !ok || j.OwnerID != ownerID {
+		return Job{}, ErrJobNotFound
+	}
+	return *j, nil
+}
+
+func (r *Registry) run(j *Job, f func(ctx context.Context) (any, error)) {
+	r.mu.Lock()
+	j.Status = Running
+	r.mu.Unlock()
+
+	result, err := func() (result any, err error) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				err = fmt.Errorf("job panicked: %v", rec)
+			}
+		}()
+		return f(context.Background())
+	}()
+
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	j.FinishedAt = &now
+	j.Result = result
+	if err != nil {
+		slog.Error(fmt.Sprintf("job %s failed: %v", j.ID, err))
+		j.Status = Failed
+		j.Error = err.Error()
+		return
+	}
+	j.Status = Succeeded
+}
+
+func (r *Registry) prune() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for jobID, j := range r.jobs {
+		if j.FinishedAt == nil || time.Since(*j.FinishedAt) < retention {
+			continue
+		}
+		if c, ok := j.Result.(Cleaner); ok {
+			c.Cleanup()
+		}
+		delete(r.jobs, jobID)
+	}
+}
@@ -79,3 +79,14 @@ func Authenticate(next func(w http.ResponseWriter, r *http.Request)) func(w http
 		next(w, r)
 	}
 }
+
+// AuthorizedStream authorizes long-lived streams like Authorized. Browsers can't set headers on an
+// EventSource, so the token may also be passed in the access_token query parameter.
+func AuthorizedStream(next func(w http.ResponseWriter, r *http.Request)) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get("access_token"); r.Header.Get("Authorization") == "" && token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		Authorized(next)(w, r)
+	}
+}
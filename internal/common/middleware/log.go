@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/citadel-corp/cats-social/internal/common/id"
@@ -27,10 +28,40 @@ func (w *LogResponseWriter) WriteHeader(code int) {
 }
 
 func (w *LogResponseWriter) Write(body []byte) (int, error) {
-	w.buf.Write(body)
+	// only error bodies are inspected, so streamed responses don't pile up in memory
+	if w.statusCode >= 500 {
+		w.buf.Write(body)
+	}
 	return w.ResponseWriter.Write(body)
 }
 
+func (w *LogResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// secretParams are query parameters that carry credentials, like the stream access token or an unsubscribe token
+var secretParams = []string{"access_token", "token"}
+
+// redactedURI returns the request uri with the values of secretParams masked, so they never reach the logs.
+func redactedURI(u *url.URL) string {
+	query := u.Query()
+	redacted := false
+	for _, param := range secretParams {
+		if query.Has(param) {
+			query.Set(param, "REDACTED")
+			redacted = true
+		}
+	}
+	if !redacted {
+		return u.RequestURI()
+	}
+	masked := *u
+	masked.RawQuery = query.Encode()
+	return masked.RequestURI()
+}
+
 func Logging(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		startTime := time.Now()
@@ -41,7 +72,7 @@ func Logging(next http.Handler) http.Handler {
 		slog.Debug("request information",
 			slog.Duration("duration", time.Since(startTime)),
 			slog.Int("status", logRespWriter.statusCode),
-			slog.String("uri", r.RequestURI),
+			slog.String("uri", redactedURI(r.URL)),
 			slog.String("requestID", requestID),
 			slog.String("method", r.Method),
 		)
@@ -6,10 +6,10 @@ import (
 )
 
 type ResponseBody struct {
-	Message string      `json:"message"`
-	Data    any         `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
-	Meta    *Pagination `json:"meta,omitempty"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Meta    any    `json:"meta,omitempty"`
 }
 
 type Pagination struct {
@@ -0,0 +1,88 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"sort"
+	"time"
+
+	"github.com/citadel-corp/cats-social/internal/common/id"
+)
+
+// Message is a rendered email with a plain-text and an HTML alternative.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	// Headers are extra headers, e.g. List-Unsubscribe
+	Headers map[string]string
+}
+
+// Mailer delivers messages.
+type Mailer interface {
+	Send(ctx context.Context, from string, msg Message) error
+}
+
+// build encodes msg as a multipart/alternative MIME message.
+func build(from string, msg Message) ([]byte, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	}
+	for _, p := range parts {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err = qw.Write([]byte(p.content)); err != nil {
+			return nil, err
+		}
+		if err = qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	var res bytes.Buffer
+	headers := map[string]string{
+		"From":         from,
+		"To":           msg.To,
+		"Subject":      mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date":         time.Now().Format(time.RFC1123Z),
+		"Message-ID":   fmt.Sprintf("<%s@cats-social>", id.GenerateStringID(24)),
+		"MIME-Version": "1.0",
+		"Content-Type": fmt.Sprintf("multipart/alternative; boundary=%q", mw.Boundary()),
+	}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&res, "%s: %s\r\n", k, headers[k])
+	}
+	res.WriteString("\r\n")
+	res.Write(body.Bytes())
+	return res.Bytes(), nil
+}
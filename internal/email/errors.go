@@ -0,0 +1,7 @@
+package email
+
+import "errors"
+
+var (
+	ErrInvalidToken = errors.New("unsubscribe token is not valid")
+)
@@ -0,0 +1,124 @@
+package email
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/citadel-corp/cats-social/internal/common/middleware"
+	"github.com/citadel-corp/cats-social/internal/common/response"
+	"github.com/citadel-corp/cats-social/internal/user"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// unsubscribePage is the page unsubscribe links open in a browser.
+type unsubscribePage struct {
+	Done  bool
+	Error string
+}
+
+// UnsubscribePage asks to confirm unsubscribing. Opening the link changes nothing, so link scanners and
+// mail clients prefetching it don't unsubscribe anyone; the page posts back to Unsubscribe.
+func (h *Handler) UnsubscribePage(w http.ResponseWriter, r *http.Request) {
+	page := unsubscribePage{}
+	status := http.StatusOK
+	err := h.service.CheckUnsubscribeToken(r.URL.Query().Get("token"))
+	if err != nil {
+		page.Error = "The unsubscribe link is not valid, open the one from your latest match email."
+		status = http.StatusBadRequest
+	}
+	renderPage(w, status, page)
+}
+
+// Unsubscribe needs no login, the signed token in the link identifies the user. Mail clients post to it
+// directly for one-click unsubscribes (RFC 8058), browsers through the confirmation page.
+func (h *Handler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	err := h.service.Unsubscribe(r.Context(), token)
+	if err == nil && wantsHTML(r) {
+		renderPage(w, http.StatusOK, unsubscribePage{Done: true})
+		return
+	}
+	if errors.Is(err, ErrInvalidToken) {
+		response.JSON(w, http.StatusBadRequest, response.ResponseBody{
+			Message: "Bad request",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, user.ErrUserNotFound) {
+		response.JSON(w, http.StatusNotFound, response.ResponseBody{
+			Message: "Not found",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
+			Message: "Internal server error",
+			Error:   err.Error(),
+		})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.ResponseBody{
+		Message: "You will no longer receive match emails",
+	})
+}
+
+func wantsHTML(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+func renderPage(w http.ResponseWriter, status int, page unsubscribePage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	err := htmlTemplates.ExecuteTemplate(w, "unsubscribe.html", page)
+	if err != nil {
+		slog.Error(fmt.Sprintf("render unsubscribe page: %v", err))
+	}
+}
+
+func (h *Handler) Resubscribe(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{})
+		return
+	}
+	err = h.service.Resubscribe(r.Context(), userID)
+	if errors.Is(err, user.ErrUserNotFound) {
+		response.JSON(w, http.StatusNotFound, response.ResponseBody{
+			Message: "Not found",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{
+			Message: "Internal server error",
+			Error:   err.Error(),
+		})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.ResponseBody{
+		Message: "success",
+	})
+}
+
+func getUserID(r *http.Request) (int64, error) {
+	if authValue, ok := r.Context().Value(middleware.ContextAuthKey{}).(string); ok {
+		return strconv.ParseInt(authValue, 10, 64)
+	} else {
+		slog.Error("cannot parse auth value from context")
+		return 0, errors.New("cannot parse auth value from context")
+	}
+}
@@ -0,0 +1,36 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/citadel-corp/cats-social/internal/common/id"
+)
+
+// FileMailer writes every message as an .eml file into a local outbox directory instead of sending it,
+// for development and tests.
+type FileMailer struct {
+	dir string
+}
+
+// NewFileMailer creates a FileMailer writing into dir, creating it if needed.
+func NewFileMailer(dir string) (*FileMailer, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+	return &FileMailer{dir: dir}, nil
+}
+
+// Send implements Mailer.
+func (m *FileMailer) Send(ctx context.Context, from string, msg Message) error {
+	raw, err := build(from, msg)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), id.GenerateStringID(8))
+	return os.WriteFile(filepath.Join(m.dir, name), raw, 0o644)
+}
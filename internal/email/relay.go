@@ -0,0 +1,31 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/citadel-corp/cats-social/internal/event"
+	"github.com/citadel-corp/cats-social/internal/outbox"
+)
+
+// NewOutboxRelay returns an outbox handler sending the emails of the user events that userEvents derives
+// from each domain event. A failed lookup hands the event over again, so a recipient may then get an
+// email twice.
+func NewOutboxRelay(service Service, userEvents func(outbox.Event) ([]event.Event, error)) outbox.Handler {
+	return func(ctx context.Context, e outbox.Event) error {
+		events, err := userEvents(e)
+		if err != nil {
+			// retrying can't fix an event that can't be read
+			slog.Error(fmt.Sprintf("email relay, outbox event %d: %v", e.ID, err))
+			return nil
+		}
+		for _, ue := range events {
+			err = service.SendEventEmail(ctx, ue.UserID, ue.Type, ue.Data)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
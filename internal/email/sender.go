@@ -0,0 +1,117 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	queueSize       = 256
+	maxAttempts     = 5
+	firstRetryDelay = 2 * time.Second
+)
+
+// Sender delivers messages in the background so requests don't wait on the mail server. A failed
+// message is set aside and retried with exponential backoff while the queue keeps moving, so one bad
+// recipient or a flapping server doesn't hold up the others.
+type Sender struct {
+	mailer Mailer
+	from   string
+	queue  chan Message
+}
+
+// retry is a failed message waiting for its next attempt.
+type retry struct {
+	msg      Message
+	attempts int
+	delay    time.Duration
+	at       time.Time
+}
+
+func NewSender(mailer Mailer, from string) *Sender {
+	return &Sender{mailer: mailer, from: from, queue: make(chan Message, queueSize)}
+}
+
+// Enqueue queues msg for delivery. The message is dropped when the queue is full.
+func (s *Sender) Enqueue(msg Message) {
+	select {
+	case s.queue <- msg:
+	default:
+		slog.Error(fmt.Sprintf("email queue is full, dropping %q to %s", msg.Subject, msg.To))
+	}
+}
+
+// Run delivers queued messages and retries failed ones until ctx is done.
+func (s *Sender) Run(ctx context.Context) {
+	var retries []retry
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		// wake up for the earliest retry, if any
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		var wake <-chan time.Time
+		if len(retries) > 0 {
+			next := retries[0].at
+			for _, r := range retries[1:] {
+				if r.at.Before(next) {
+					next = r.at
+				}
+			}
+			timer.Reset(time.Until(next))
+			wake = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			if n := len(s.queue) + len(retries); n > 0 {
+				slog.Error(fmt.Sprintf("email sender stopped with %d messages queued", n))
+			}
+			return
+		case msg := <-s.queue:
+			retries = s.deliver(ctx, retry{msg: msg, delay: firstRetryDelay}, retries)
+		case <-wake:
+			now := time.Now()
+			var pending []retry
+			due := make([]retry, 0)
+			for _, r := range retries {
+				if r.at.After(now) {
+					pending = append(pending, r)
+				} else {
+					due = append(due, r)
+				}
+			}
+			retries = pending
+			for _, r := range due {
+				retries = s.deliver(ctx, r, retries)
+			}
+		}
+	}
+}
+
+// deliver makes one attempt at r and returns retries with r added back when it failed and has attempts left.
+func (s *Sender) deliver(ctx context.Context, r retry, retries []retry) []retry {
+	r.attempts++
+	err := s.mailer.Send(ctx, s.from, r.msg)
+	if err == nil {
+		return retries
+	}
+	if r.attempts == maxAttempts {
+		slog.Error(fmt.Sprintf("email %q to %s failed after %d attempts: %v", r.msg.Subject, r.msg.To, r.attempts, err))
+		return retries
+	}
+	if len(retries) >= queueSize {
+		slog.Error(fmt.Sprintf("email retry queue is full, dropping %q to %s: %v", r.msg.Subject, r.msg.To, err))
+		return retries
+	}
+	slog.Warn(fmt.Sprintf("email %q to %s failed, retrying in %s: %v", r.msg.Subject, r.msg.To, r.delay, err))
+	r.at = time.Now().Add(r.delay)
+	r.delay *= 2
+	return append(retries, r)
+}
@@ -0,0 +1,199 @@
+package email
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/citadel-corp/cats-social/internal/cat"
+	catmatch "github.com/citadel-corp/cats-social/internal/cat_match"
+	"github.com/citadel-corp/cats-social/internal/event"
+	"github.com/citadel-corp/cats-social/internal/user"
+)
+
+// Service sends the transactional emails. Match emails are sent for user events, see NewOutboxRelay, and it
+// is a user.Notifier for the account emails.
+type Service interface {
+	user.Notifier
+	// SendEventEmail sends the email for a user event, nothing for events without one
+	SendEventEmail(ctx context.Context, userID int64, typ event.Type, data any) error
+	// CheckUnsubscribeToken tells whether token would unsubscribe someone, without doing it
+	CheckUnsubscribeToken(token string) error
+	Unsubscribe(ctx context.Context, token string) error
+	Resubscribe(ctx context.Context, userID int64) error
+}
+
+// Config holds what emails link to.
+type Config struct {
+	// BaseURL is the public URL of the service, linked from emails
+	BaseURL string
+	// Secret signs unsubscribe links
+	Secret string
+}
+
+// matchEmails is the email sent for each match event, to either the issuer or the receiver of the match.
+var matchEmails = map[event.Type]struct {
+	template string
+	toIssuer bool
+}{
+	event.MatchReceived: {template: "match_received", toIssuer: false},
+	event.MatchApproved: {template: "match_approved", toIssuer: true},
+	event.MatchRejected: {template: "match_rejected", toIssuer: true},
+}
+
+type emailService struct {
+	sender             *Sender
+	config             Config
+	userRepository     user.Repository
+	catRepository      cat.Repository
+	catMatchRepository catmatch.Repository
+}
+
+func NewService(sender *Sender, config Config, userRepository user.Repository, catRepository cat.Repository, catMatchRepository catmatch.Repository) Service {
+	return &emailService{
+		sender:             sender,
+		config:             config,
+		userRepository:     userRepository,
+		catRepository:      catRepository,
+		catMatchRepository: catMatchRepository,
+	}
+}
+
+// SendEventEmail implements Service. Users who unsubscribed get no match emails.
+func (s *emailService) SendEventEmail(ctx context.Context, userID int64, typ event.Type, data any) error {
+	matchEmail, ok := matchEmails[typ]
+	if !ok {
+		return nil
+	}
+	matchData, ok := data.(catmatch.MatchEventData)
+	if !ok {
+		return nil
+	}
+	return s.sendMatchEmail(ctx, userID, matchData.ID, matchEmail.template, matchEmail.toIssuer)
+}
+
+func (s *emailService) sendMatchEmail(ctx context.Context, userID int64, matchID string, template string, toIssuer bool) error {
+	match, err := s.catMatchRepository.GetByUIDAndUserID(ctx, matchID, userID)
+	if err != nil {
+		return err
+	}
+	// rejections of a cat's other requests also reach their receivers, who didn't ask for anything
+	if toIssuer != (match.IssueUserId == userID) {
+		return nil
+	}
+	recipient, err := s.userRepository.GetByID(ctx, uint64(userID))
+	if err != nil {
+		return err
+	}
+	if recipient.EmailUnsubscribedAt != nil {
+		return nil
+	}
+
+	catID, otherCatID, otherUserID := match.MatchCatId, match.IssuerCatId, match.IssueUserId
+	if toIssuer {
+		catID, otherCatID, otherUserID = match.IssuerCatId, match.MatchCatId, match.MatchUserId
+	}
+	c, err := s.catRepository.GetByIDAndUserID(ctx, catID, userID)
+	if err != nil {
+		return err
+	}
+	otherCat, err := s.catRepository.GetByIDAndUserID(ctx, otherCatID, otherUserID)
+	if err != nil {
+		return err
+	}
+	otherUser, err := s.userRepository.GetByID(ctx, uint64(otherUserID))
+	if err != nil {
+		return err
+	}
+
+	msg, err := render(template, recipient.Email, templateData{
+		Name:           recipient.Name,
+		OtherName:      otherUser.Name,
+		CatName:        c.Name,
+		OtherCatName:   otherCat.Name,
+		Message:        match.Message,
+		AppURL:         s.config.BaseURL,
+		UnsubscribeURL: s.unsubscribeURL(userID),
+	})
+	if err != nil {
+		return err
+	}
+	s.sender.Enqueue(msg)
+	return nil
+}
+
+// Registered implements user.Notifier.
+func (s *emailService) Registered(ctx context.Context, u *user.User) {
+	s.sendAccountEmail("welcome", u)
+}
+
+// LoggedIn implements user.Notifier.
+func (s *emailService) LoggedIn(ctx context.Context, u *user.User) {
+	s.sendAccountEmail("login", u)
+}
+
+// sendAccountEmail sends an email about the account itself, those are sent even to unsubscribed users.
+func (s *emailService) sendAccountEmail(template string, u *user.User) {
+	msg, err := render(template, u.Email, templateData{
+		Name:   u.Name,
+		Time:   time.Now(),
+		AppURL: s.config.BaseURL,
+	})
+	if err != nil {
+		slog.Error(fmt.Sprintf("%s email for user %d: %v", template, u.ID, err))
+		return
+	}
+	s.sender.Enqueue(msg)
+}
+
+// CheckUnsubscribeToken implements Service.
+func (s *emailService) CheckUnsubscribeToken(token string) error {
+	_, err := s.parseToken(token)
+	return err
+}
+
+// Unsubscribe implements Service. The token comes from the link in a match email.
+func (s *emailService) Unsubscribe(ctx context.Context, token string) error {
+	userID, err := s.parseToken(token)
+	if err != nil {
+		return err
+	}
+	return s.userRepository.SetEmailSubscribed(ctx, userID, false)
+}
+
+// parseToken returns the user an unsubscribe token was signed for.
+func (s *emailService) parseToken(token string) (int64, error) {
+	rawID, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.sign(rawID))) {
+		return 0, ErrInvalidToken
+	}
+	userID, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidToken
+	}
+	return userID, nil
+}
+
+// Resubscribe implements Service.
+func (s *emailService) Resubscribe(ctx context.Context, userID int64) error {
+	return s.userRepository.SetEmailSubscribed(ctx, userID, true)
+}
+
+func (s *emailService) unsubscribeURL(userID int64) string {
+	rawID := strconv.FormatInt(userID, 10)
+	token := rawID + "." + s.sign(rawID)
+	return s.config.BaseURL + "/v1/email/unsubscribe?token=" + url.QueryEscape(token)
+}
+
+func (s *emailService) sign(rawID string) string {
+	mac := hmac.New(sha256.New, []byte(s.config.Secret))
+	mac.Write([]byte("unsubscribe:" + rawID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
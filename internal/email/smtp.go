@@ -0,0 +1,31 @@
+package email
+
+import (
+	"context"
+	"net"
+	"net/smtp"
+)
+
+// SMTPMailer sends messages through an SMTP server.
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer creates an SMTPMailer for host:port, authenticating with PLAIN auth when username is set.
+func NewSMTPMailer(host, port, username, password string) *SMTPMailer {
+	m := &SMTPMailer{addr: net.JoinHostPort(host, port)}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m
+}
+
+// Send implements Mailer.
+func (m *SMTPMailer) Send(ctx context.Context, from string, msg Message) error {
+	raw, err := build(from, msg)
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(m.addr, m.auth, from, []string{msg.To}, raw)
+}
@@ -0,0 +1,59 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+var (
+	htmlTemplates = htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/*.html"))
+	textTemplates = texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/*.txt"))
+)
+
+// templateData is what every template renders from, fields a template doesn't use are left empty.
+type templateData struct {
+	Name           string
+	OtherName      string
+	CatName        string
+	OtherCatName   string
+	Message        string
+	Time           time.Time
+	AppURL         string
+	UnsubscribeURL string
+}
+
+// render renders the subject, text and HTML bodies of the named email for to.
+func render(name string, to string, data templateData) (Message, error) {
+	msg := Message{To: to}
+	var buf bytes.Buffer
+	if err := textTemplates.ExecuteTemplate(&buf, name+".subject", data); err != nil {
+		return msg, err
+	}
+	msg.Subject = buf.String()
+
+	buf.Reset()
+	if err := textTemplates.ExecuteTemplate(&buf, name+".text", data); err != nil {
+		return msg, err
+	}
+	msg.Text = buf.String()
+
+	buf.Reset()
+	if err := htmlTemplates.ExecuteTemplate(&buf, name+".html", data); err != nil {
+		return msg, err
+	}
+	msg.HTML = buf.String()
+
+	if data.UnsubscribeURL != "" {
+		msg.Headers = map[string]string{
+			"List-Unsubscribe":      "<" + data.UnsubscribeURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		}
+	}
+	return msg, nil
+}
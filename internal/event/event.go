@@ -0,0 +1,58 @@
+package event
+
+import (
+	"context"
+	"time"
+)
+
+type Type string
+
+const (
+	CatCreated      Type = "cat.created"
+	CatUpdated      Type = "cat.updated"
+	CatDeleted      Type = "cat.deleted"
+	CatTransferred  Type = "cat.transferred"
+	MatchReceived   Type = "match.received"
+	MatchApproved   Type = "match.approved"
+	MatchRejected   Type = "match.rejected"
+	MatchWithdrawn  Type = "match.withdrawn"
+	MatchExpired    Type = "match.expired"
+	MatchExpiring   Type = "match.expiring"
+	MatchDissolved  Type = "match.dissolved"
+	MatchCancelled  Type = "match.cancelled"
+	MessageReceived Type = "message.received"
+)
+
+// Types lists every event type.
+var Types = []Type{CatCreated, CatUpdated, CatDeleted, CatTransferred, MatchReceived, MatchApproved, MatchRejected, MatchWithdrawn, MatchExpiring, MatchExpired, MatchDissolved, MatchCancelled, MessageReceived}
+
+// Event is something that happened to a user, pushed to their connected clients.
+type Event struct {
+	ID        int64
+	Type      Type
+	UserID    int64
+	Data      any
+	CreatedAt time.Time
+}
+
+// Publisher delivers events to a user. Publishing never fails the caller's operation, events
+// that can't be delivered are dropped.
+type Publisher interface {
+	Publish(ctx context.Context, userID int64, typ Type, data any)
+}
+
+// Discard is a Publisher dropping every event, for services running without push delivery.
+var Discard Publisher = discard{}
+
+type discard struct{}
+
+func (discard) Publish(ctx context.Context, userID int64, typ Type, data any) {}
+
+// Fanout is a Publisher handing every event to each of its publishers in turn.
+type Fanout []Publisher
+
+func (f Fanout) Publish(ctx context.Context, userID int64, typ Type, data any) {
+	for _, p := range f {
+		p.Publish(ctx, userID, typ, data)
+	}
+}
@@ -0,0 +1,131 @@
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/citadel-corp/cats-social/internal/common/middleware"
+	"github.com/citadel-corp/cats-social/internal/common/response"
+)
+
+const (
+	heartbeatInterval = 25 * time.Second
+	// retryMillis tells clients how long to wait before reconnecting
+	retryMillis = 3000
+)
+
+type Handler struct {
+	hub  *Hub
+	done chan struct{}
+	once sync.Once
+}
+
+func NewHandler(hub *Hub) *Handler {
+	return &Handler{hub: hub, done: make(chan struct{})}
+}
+
+// Close ends every open stream. The server waits for active requests when shutting down, and streams
+// never finish on their own, so Close is registered to run on shutdown.
+func (h *Handler) Close() {
+	h.once.Do(func() { close(h.done) })
+}
+
+// Stream sends the user's events as server-sent events until the client disconnects or the handler is
+// closed. Clients resume after a reconnect with the Last-Event-ID header, or the lastEventId query parameter.
+func (h *Handler) Stream(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.ResponseBody{})
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+	var lastID int64
+	if lastEventID != "" {
+		lastID, err = strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, response.ResponseBody{
+				Message: "Bad request",
+				Error:   "invalid last event id",
+			})
+			return
+		}
+	}
+
+	rc := http.NewResponseController(w)
+	sub, missed := h.hub.Subscribe(userID, lastID)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// keep proxies from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	_, err = fmt.Fprintf(w, "retry: %d\n\n", retryMillis)
+	if err != nil {
+		return
+	}
+	for _, e := range missed {
+		if err = writeEvent(w, e); err != nil {
+			return
+		}
+	}
+	if err = rc.Flush(); err != nil {
+		slog.Error(fmt.Sprintf("event stream can't be flushed: %v", err))
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.done:
+			return
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(w, ": ping\n\n")
+		case e, ok := <-sub.C:
+			if !ok {
+				// dropped for falling behind, the client reconnects with its last event id
+				return
+			}
+			err = writeEvent(w, e)
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, e Event) error {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
+
+func getUserID(r *http.Request) (int64, error) {
+	if authValue, ok := r.Context().Value(middleware.ContextAuthKey{}).(string); ok {
+		return strconv.ParseInt(authValue, 10, 64)
+	} else {
+		slog.Error("cannot parse auth value from context")
+		return 0, errors.New("cannot parse auth value from context")
+	}
+}
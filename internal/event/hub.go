@@ -0,0 +1,116 @@
+package event
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBacklog is how many recent events are kept for clients reconnecting with Last-Event-ID
+	DefaultBacklog = 1024
+	// subscriberBuffer is how many events a slow client may lag behind before it is disconnected
+	subscriberBuffer = 32
+)
+
+// Hub is an in-process pub/sub fanning events out to every subscription of their user. It keeps the
+// last events in a ring so reconnecting clients can catch up on what they missed.
+type Hub struct {
+	mu          sync.Mutex
+	lastID      int64
+	subscribers map[int64]map[*Subscription]struct{}
+	recent      []Event
+	next        int
+}
+
+// NewHub creates a Hub keeping backlog events for replay, DefaultBacklog if not positive.
+func NewHub(backlog int) *Hub {
+	if backlog <= 0 {
+		backlog = DefaultBacklog
+	}
+	return &Hub{
+		// ids keep increasing across restarts, so a Last-Event-ID from before a restart replays the whole backlog
+		lastID:      time.Now().UnixMicro(),
+		subscribers: make(map[int64]map[*Subscription]struct{}),
+		recent:      make([]Event, 0, backlog),
+	}
+}
+
+// Subscription receives the events of one user until it is closed.
+type Subscription struct {
+	C      <-chan Event
+	c      chan Event
+	hub    *Hub
+	userID int64
+	closed bool
+}
+
+// Publish implements Publisher.
+func (h *Hub) Publish(ctx context.Context, userID int64, typ Type, data any) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID += 1
+	e := Event{ID: h.lastID, Type: typ, UserID: userID, Data: data, CreatedAt: time.Now()}
+	if len(h.recent) < cap(h.recent) {
+		h.recent = append(h.recent, e)
+	} else {
+		h.recent[h.next] = e
+		h.next = (h.next + 1) % len(h.recent)
+	}
+
+	for s := range h.subscribers[userID] {
+		select {
+		case s.c <- e:
+		default:
+			// the client can't keep up, it reconnects and replays from its last event
+			h.remove(s)
+		}
+	}
+}
+
+// Subscribe registers a subscription for userID and returns it with the user's recent events newer
+// than lastEventID, oldest first. A zero lastEventID replays nothing.
+func (h *Hub) Subscribe(userID int64, lastEventID int64) (*Subscription, []Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c := make(chan Event, subscriberBuffer)
+	s := &Subscription{C: c, c: c, hub: h, userID: userID}
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[*Subscription]struct{})
+	}
+	h.subscribers[userID][s] = struct{}{}
+
+	missed := make([]Event, 0)
+	if lastEventID == 0 {
+		return s, missed
+	}
+	for i := range h.recent {
+		e := h.recent[(h.next+i)%len(h.recent)]
+		if e.UserID == userID && e.ID > lastEventID {
+			missed = append(missed, e)
+		}
+	}
+	return s, missed
+}
+
+// Close unregisters the subscription and closes its channel.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
+
+// remove must be called with h.mu held.
+func (h *Hub) remove(s *Subscription) {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.c)
+	delete(h.subscribers[s.userID], s)
+	if len(h.subscribers[s.userID]) == 0 {
+		delete(h.subscribers, s.userID)
+	}
+}
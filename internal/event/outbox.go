@@ -0,0 +1,26 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/citadel-corp/cats-social/internal/outbox"
+)
+
+// NewOutboxRelay returns an outbox handler publishing the user events that userEvents derives from each
+// domain event through publisher.
+func NewOutboxRelay(publisher Publisher, userEvents func(outbox.Event) ([]Event, error)) outbox.Handler {
+	return func(ctx context.Context, e outbox.Event) error {
+		events, err := userEvents(e)
+		if err != nil {
+			// retrying can't fix an event that can't be read
+			slog.Error(fmt.Sprintf("event relay, outbox event %d: %v", e.ID, err))
+			return nil
+		}
+		for _, ue := range events {
+			publisher.Publish(ctx, ue.UserID, ue.Type, ue.Data)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,9 @@
+package export
+
+import "errors"
+
+var (
+	ErrValidationFailed = errors.New("validation failed")
+	ErrJobNotFound      = errors.New("export job not found")
+	ErrJobNotReady      = errors.New("export job is not finished")
+)
@@ -0,0 +1,48 @@
+package export
+
+import (
+	"fmt"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+	FormatZip  = "zip"
+
+	DatasetCats    = "cats"
+	DatasetMatches = "matches"
+	DatasetProfile = "profile"
+)
+
+type ExportPayload struct {
+	Format  string `schema:"format" binding:"omitempty"`
+	Dataset string `schema:"dataset" binding:"omitempty"`
+	Async   bool   `schema:"async" binding:"omitempty"`
+}
+
+func (p ExportPayload) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Format, validation.Required, validation.In(FormatCSV, FormatJSON, FormatZip)),
+		validation.Field(&p.Dataset, validation.In(DatasetCats, DatasetMatches, DatasetProfile)),
+	)
+}
+
+func (p ExportPayload) ContentType() string {
+	switch p.Format {
+	case FormatCSV:
+		return "text/csv"
+	case FormatZip:
+		return "application/zip"
+	default:
+		return "application/json"
+	}
+}
+
+func (p ExportPayload) Filename() string {
+	if p.Format == FormatCSV {
+		return fmt.Sprintf("cats-social-%s.csv", p.Dataset)
+	}
+	return fmt.Sprintf("cats-social-export.%s", p.Format)
+}
@@ -0,0 +1,44 @@
+package export
+
+import "time"
+
+type ExportJobResponse struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	DownloadURL string     `json:"downloadUrl,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+}
+
+type ProfileResponse struct {
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	City      string    `json:"city,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type CatResponse struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Race        string    `json:"race"`
+	Sex         string    `json:"sex"`
+	AgeInMonth  int       `json:"ageInMonth"`
+	Description string    `json:"description"`
+	ImageUrls   []string  `json:"imageUrls"`
+	HasMatched  bool      `json:"hasMatched"`
+	City        string    `json:"city,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type MatchResponse struct {
+	ID           string    `json:"id"`
+	Direction    string    `json:"direction"`
+	Status       string    `json:"status"`
+	Message      string    `json:"message"`
+	UserCatID    string    `json:"userCatId"`
+	UserCatName  string    `json:"userCatName"`
+	MatchCatID   string    `json:"matchCatId"`
+	MatchCatName string    `json:"matchCatName"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
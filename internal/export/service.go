@@ -0,0 +1,159 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/citadel-corp/cats-social/internal/cat"
+	catmatch "github.com/citadel-corp/cats-social/internal/cat_match"
+	"github.com/citadel-corp/cats-social/internal/common/job"
+	"github.com/citadel-corp/cats-social/internal/user"
+)
+
+// exports with more rows than this are written to a file by a background job
+const asyncThreshold = 5000
+
+type Service interface {
+	Prepare(ctx context.Context, req ExportPayload, userID int64) (*ExportJobResponse, error)
+	Stream(ctx context.Context, req ExportPayload, userID int64, w io.Writer) error
+	GetJob(ctx context.Context, jobID string, userID int64) (*ExportJobResponse, error)
+	GetDownload(ctx context.Context, jobID string, userID int64) (*File, error)
+}
+
+// File is an export written to disk by a background job.
+type File struct {
+	Path        string
+	Filename    string
+	ContentType string
+}
+
+// Cleanup implements job.Cleaner.
+func (f *File) Cleanup() {
+	os.Remove(f.Path)
+}
+
+type exportService struct {
+	userRepository     user.Repository
+	catRepository      cat.Repository
+	catMatchRepository catmatch.Repository
+	jobs               *job.Registry
+}
+
+func NewService(userRepository user.Repository, catRepository cat.Repository, catMatchRepository catmatch.Repository) Service {
+	return &exportService{
+		userRepository:     userRepository,
+		catRepository:      catRepository,
+		catMatchRepository: catMatchRepository,
+		jobs:               job.NewRegistry(),
+	}
+}
+
+// Prepare implements Service. It starts a background job for large or explicitly async exports;
+// a nil job means the export is small enough to be streamed with Stream.
+func (s *exportService) Prepare(ctx context.Context, req ExportPayload, userID int64) (*ExportJobResponse, error) {
+	req, err := validate(req)
+	if err != nil {
+		return nil, err
+	}
+	if !req.Async {
+		catCount, err := s.catRepository.CountByUserID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		matchCount, err := s.catMatchRepository.CountByUserID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if catCount+matchCount <= asyncThreshold {
+			return nil, nil
+		}
+	}
+
+	j := s.jobs.Start(userID, func(ctx context.Context) (any, error) {
+		return s.writeFile(ctx, req, userID)
+	})
+	return makeJobResponse(j), nil
+}
+
+// Stream implements Service.
+func (s *exportService) Stream(ctx context.Context, req ExportPayload, userID int64, w io.Writer) error {
+	req, err := validate(req)
+	if err != nil {
+		return err
+	}
+	return s.write(ctx, req, userID, w)
+}
+
+// GetJob implements Service.
+func (s *exportService) GetJob(ctx context.Context, jobID string, userID int64) (*ExportJobResponse, error) {
+	j, err := s.jobs.Get(jobID, userID)
+	if errors.Is(err, job.ErrJobNotFound) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return makeJobResponse(j), nil
+}
+
+// GetDownload implements Service.
+func (s *exportService) GetDownload(ctx context.Context, jobID string, userID int64) (*File, error) {
+	j, err := s.jobs.Get(jobID, userID)
+	if errors.Is(err, job.ErrJobNotFound) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	f, ok := j.Result.(*File)
+	if j.Status != job.Succeeded || !ok {
+		return nil, ErrJobNotReady
+	}
+	return f, nil
+}
+
+func (s *exportService) writeFile(ctx context.Context, req ExportPayload, userID int64) (*File, error) {
+	dir := filepath.Join(os.TempDir(), "cats-social-exports")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp(dir, "export-*."+req.Format)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	res := &File{Path: f.Name(), Filename: req.Filename(), ContentType: req.ContentType()}
+	if err = s.write(ctx, req, userID, f); err != nil {
+		res.Cleanup()
+		return nil, err
+	}
+	return res, nil
+}
+
+func validate(req ExportPayload) (ExportPayload, error) {
+	if req.Format == FormatCSV && req.Dataset == "" {
+		req.Dataset = DatasetCats
+	}
+	if err := req.Validate(); err != nil {
+		return req, fmt.Errorf("%w: %w", ErrValidationFailed, err)
+	}
+	return req, nil
+}
+
+func makeJobResponse(j job.Job) *ExportJobResponse {
+	res := &ExportJobResponse{
+		ID:         j.ID,
+		Status:     string(j.Status),
+		Error:      j.Error,
+		CreatedAt:  j.CreatedAt,
+		FinishedAt: j.FinishedAt,
+	}
+	if j.Status == job.Succeeded {
+		res.DownloadURL = fmt.Sprintf("/v1/user/me/export/%s/download", j.ID)
+	}
+	return res
+}
@@ -0,0 +1,213 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/citadel-corp/cats-social/internal/cat"
+	catmatch "github.com/citadel-corp/cats-social/internal/cat_match"
+)
+
+const (
+	directionSent     = "sent"
+	directionReceived = "received"
+)
+
+var (
+	profileCSVHeader = []string{"name", "email", "city", "createdAt"}
+	catsCSVHeader    = []string{"id", "name", "race", "sex", "ageInMonth", "description", "imageUrls", "hasMatched", "city", "createdAt"}
+	matchesCSVHeader = []string{"id", "direction", "status", "message", "userCatId", "userCatName", "matchCatId", "matchCatName", "createdAt"}
+)
+
+func (s *exportService) write(ctx context.Context, req ExportPayload, userID int64, w io.Writer) error {
+	switch req.Format {
+	case FormatCSV:
+		return s.writeCSV(ctx, req.Dataset, userID, w)
+	case FormatZip:
+		return s.writeZip(ctx, userID, w)
+	default:
+		return s.writeJSON(ctx, userID, w)
+	}
+}
+
+func (s *exportService) writeJSON(ctx context.Context, userID int64, w io.Writer) error {
+	profile, err := s.profile(ctx, userID)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	bw.WriteString(`{"profile":`)
+	if err = enc.Encode(profile); err != nil {
+		return err
+	}
+
+	bw.WriteString(`,"cats":[`)
+	first := true
+	err = s.catRepository.EachByUserID(ctx, userID, func(c *cat.Cat) error {
+		if !first {
+			bw.WriteString(",")
+		}
+		first = false
+		return enc.Encode(makeCatResponse(c))
+	})
+	if err != nil {
+		return err
+	}
+
+	bw.WriteString(`],"matches":[`)
+	first = true
+	err = s.catMatchRepository.EachByUserID(ctx, userID, func(m *catmatch.CatMatchHistory) error {
+		if !first {
+			bw.WriteString(",")
+		}
+		first = false
+		return enc.Encode(makeMatchResponse(m, userID))
+	})
+	if err != nil {
+		return err
+	}
+	bw.WriteString("]}\n")
+	return bw.Flush()
+}
+
+func (s *exportService) writeCSV(ctx context.Context, dataset string, userID int64, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	var err error
+	switch dataset {
+	case DatasetProfile:
+		err = s.writeProfileCSV(ctx, userID, cw)
+	case DatasetMatches:
+		err = s.writeMatchesCSV(ctx, userID, cw)
+	default:
+		err = s.writeCatsCSV(ctx, userID, cw)
+	}
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (s *exportService) writeZip(ctx context.Context, userID int64, w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	f, err := zw.Create("profile.csv")
+	if err != nil {
+		return err
+	}
+	if err = s.writeCSV(ctx, DatasetProfile, userID, f); err != nil {
+		return err
+	}
+	f, err = zw.Create("cats.csv")
+	if err != nil {
+		return err
+	}
+	if err = s.writeCSV(ctx, DatasetCats, userID, f); err != nil {
+		return err
+	}
+	f, err = zw.Create("matches.csv")
+	if err != nil {
+		return err
+	}
+	if err = s.writeCSV(ctx, DatasetMatches, userID, f); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func (s *exportService) writeProfileCSV(ctx context.Context, userID int64, cw *csv.Writer) error {
+	profile, err := s.profile(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err = cw.Write(profileCSVHeader); err != nil {
+		return err
+	}
+	return cw.Write([]string{profile.Name, profile.Email, profile.City, profile.CreatedAt.Format(time.RFC3339)})
+}
+
+func (s *exportService) writeCatsCSV(ctx context.Context, userID int64, cw *csv.Writer) error {
+	if err := cw.Write(catsCSVHeader); err != nil {
+		return err
+	}
+	return s.catRepository.EachByUserID(ctx, userID, func(c *cat.Cat) error {
+		res := makeCatResponse(c)
+		return cw.Write([]string{
+			res.ID, res.Name, res.Race, res.Sex, strconv.Itoa(res.AgeInMonth), res.Description,
+			strings.Join(res.ImageUrls, "|"), strconv.FormatBool(res.HasMatched), res.City, res.CreatedAt.Format(time.RFC3339),
+		})
+	})
+}
+
+func (s *exportService) writeMatchesCSV(ctx context.Context, userID int64, cw *csv.Writer) error {
+	if err := cw.Write(matchesCSVHeader); err != nil {
+		return err
+	}
+	return s.catMatchRepository.EachByUserID(ctx, userID, func(m *catmatch.CatMatchHistory) error {
+		res := makeMatchResponse(m, userID)
+		return cw.Write([]string{
+			res.ID, res.Direction, res.Status, res.Message, res.UserCatID, res.UserCatName,
+			res.MatchCatID, res.MatchCatName, res.CreatedAt.Format(time.RFC3339),
+		})
+	})
+}
+
+func (s *exportService) profile(ctx context.Context, userID int64) (*ProfileResponse, error) {
+	u, err := s.userRepository.GetByID(ctx, uint64(userID))
+	if err != nil {
+		return nil, fmt.Errorf("get profile: %w", err)
+	}
+	return &ProfileResponse{
+		Name:      u.Name,
+		Email:     u.Email,
+		City:      u.City,
+		CreatedAt: u.CreatedAt,
+	}, nil
+}
+
+func makeCatResponse(c *cat.Cat) CatResponse {
+	res := CatResponse{
+		ID:          c.UID,
+		Name:        c.Name,
+		Race:        string(c.Race),
+		Sex:         string(c.Sex),
+		AgeInMonth:  c.Age,
+		Description: c.Description,
+		ImageUrls:   c.ImageURLS,
+		HasMatched:  c.HasMatched,
+		CreatedAt:   c.CreatedAt,
+	}
+	if c.Location != nil {
+		res.City = c.Location.City
+	}
+	return res
+}
+
+func makeMatchResponse(m *catmatch.CatMatchHistory, userID int64) MatchResponse {
+	res := MatchResponse{
+		ID:        m.UID,
+		Status:    string(m.ApprovalStatus),
+		Message:   m.Message,
+		CreatedAt: m.CreatedAt,
+	}
+	if m.IssuerUserId == userID {
+		res.Direction = directionSent
+		res.UserCatID, res.UserCatName = m.IssuerCatUID, m.IssuerCatName
+		res.MatchCatID, res.MatchCatName = m.MatchCatUID, m.MatchCatName
+	} else {
+		res.Direction = directionReceived
+		res.UserCatID, res.UserCatName = m.MatchCatUID, m.MatchCatName
+		res.MatchCatID, res.MatchCatName = m.IssuerCatUID, m.IssuerCatName
+	}
+	return res
+}
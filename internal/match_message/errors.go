@@ -0,0 +1,14 @@
+package matchmessage
+
+import (
+	"errors"
+
+	"github.com/citadel-corp/cats-social/internal/common/cursor"
+)
+
+var (
+	ErrMessageNotFound  = errors.New("message not found")
+	ErrThreadClosed     = errors.New("cat match is no longer open for messages")
+	ErrValidationFailed = errors.New("validation failed")
+	ErrInvalidCursor    = cursor.ErrInvalidCursor
+)
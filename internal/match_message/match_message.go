@@ -0,0 +1,20 @@
+package matchmessage
+
+import "time"
+
+type MatchMessage struct {
+	ID           int64
+	UID          string
+	MatchID      int64
+	SenderUserID int64
+	Body         string
+	CreatedAt    time.Time
+	// ReadAt is set once the other participant has read the message
+	ReadAt *time.Time
+}
+
+// MatchMessageList is a message joined with the name of its sender.
+type MatchMessageList struct {
+	MatchMessage
+	SenderName string
+}
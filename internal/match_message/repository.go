@@ -0,0 +1,116 @@
+package matchmessage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/citadel-corp/cats-social/internal/common/db"
+)
+
+type Repository interface {
+	Create(ctx context.Context, message *MatchMessage) error
+	GetListByUID(ctx context.Context, uid string) (*MatchMessageList, error)
+	List(ctx context.Context, matchID int64, req ListMessagePayload) ([]MatchMessageList, error)
+	CountUnread(ctx context.Context, matchID int64, readerID int64) (int, error)
+	MarkRead(ctx context.Context, matchID int64, readerID int64) (int64, error)
+}
+
+type dbRepository struct {
+	db *db.DB
+}
+
+func NewRepository(db *db.DB) Repository {
+	return &dbRepository{db: db}
+}
+
+const listMessageQuery = `
+	SELECT m.id, m.uid, m.match_id, m.sender_user_id, m.body, m.created_at, m.read_at, u.name
+	FROM match_messages m
+	JOIN users u on m.sender_user_id = u.id
+`
+
+// Create implements Repository.
+func (d *dbRepository) Create(ctx context.Context, message *MatchMessage) error {
+	createMessageQuery := `
+		INSERT INTO match_messages (
+			uid, match_id, sender_user_id, body
+		) VALUES (
+			$1, $2, $3, $4
+		) RETURNING id, created_at;
+	`
+	row := d.db.DB().QueryRowContext(ctx, createMessageQuery, message.UID, message.MatchID, message.SenderUserID, message.Body)
+	return row.Scan(&message.ID, &message.CreatedAt)
+}
+
+// GetListByUID implements Repository.
+func (d *dbRepository) GetListByUID(ctx context.Context, uid string) (*MatchMessageList, error) {
+	rows, err := d.db.DB().QueryContext(ctx, listMessageQuery+"WHERE m.uid = $1;", uid)
+	if err != nil {
+		return nil, err
+	}
+	res, err := scanMessageList(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, ErrMessageNotFound
+	}
+	return &res[0], nil
+}
+
+// List implements Repository. Messages are returned newest first.
+func (d *dbRepository) List(ctx context.Context, matchID int64, req ListMessagePayload) ([]MatchMessageList, error) {
+	listQuery := listMessageQuery + "WHERE m.match_id = $1 "
+	params := []interface{}{matchID}
+	if req.After != nil {
+		listQuery += fmt.Sprintf("AND (m.created_at, m.id) < ($%d::TIMESTAMP, $%d) ", len(params)+1, len(params)+2)
+		params = append(params, req.After.Timestamp(), req.After.ID)
+	}
+	listQuery += fmt.Sprintf("ORDER BY m.created_at DESC, m.id DESC LIMIT %d;", req.Limit)
+	rows, err := d.db.DB().QueryContext(ctx, listQuery, params...)
+	if err != nil {
+		return nil, err
+	}
+	return scanMessageList(rows)
+}
+
+// CountUnread implements Repository.
+func (d *dbRepository) CountUnread(ctx context.Context, matchID int64, readerID int64) (int, error) {
+	countUnreadQuery := `
+		SELECT COUNT(*)
+		FROM match_messages
+		WHERE match_id = $1 AND sender_user_id <> $2 AND read_at IS NULL;
+	`
+	var count int
+	err := d.db.DB().QueryRowContext(ctx, countUnreadQuery, matchID, readerID).Scan(&count)
+	return count, err
+}
+
+// MarkRead implements Repository. Every message the reader received in the thread is marked read.
+func (d *dbRepository) MarkRead(ctx context.Context, matchID int64, readerID int64) (int64, error) {
+	markReadQuery := `
+		UPDATE match_messages
+		SET read_at = current_timestamp
+		WHERE match_id = $1 AND sender_user_id <> $2 AND read_at IS NULL;
+	`
+	res, err := d.db.DB().ExecContext(ctx, markReadQuery, matchID, readerID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func scanMessageList(rows *sql.Rows) ([]MatchMessageList, error) {
+	defer rows.Close()
+	res := make([]MatchMessageList, 0)
+	for rows.Next() {
+		m := MatchMessageList{}
+		err := rows.Scan(&m.ID, &m.UID, &m.MatchID, &m.SenderUserID, &m.Body, &m.CreatedAt, &m.ReadAt, &m.SenderName)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, m)
+	}
+	return res, rows.Err()
+}
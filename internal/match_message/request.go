@@ -0,0 +1,29 @@
+package matchmessage
+
+import (
+	"github.com/citadel-corp/cats-social/internal/common/cursor"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+type SendMessagePayload struct {
+	Body string `json:"body"`
+}
+
+func (p SendMessagePayload) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Body, validation.Required, validation.Length(1, 1000)),
+	)
+}
+
+type ListMessagePayload struct {
+	Cursor string `schema:"cursor" binding:"omitempty"`
+	Limit  int    `schema:"limit" binding:"omitempty"`
+
+	After *cursor.Cursor `schema:"-"`
+}
+
+func (p ListMessagePayload) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Limit, validation.Min(0), validation.Max(100)),
+	)
+}
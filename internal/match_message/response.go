@@ -0,0 +1,36 @@
+package matchmessage
+
+import "time"
+
+type MessageResponse struct {
+	ID         string     `json:"id"`
+	Body       string     `json:"body"`
+	SenderName string     `json:"senderName"`
+	IsMine     bool       `json:"isMine"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ReadAt     *time.Time `json:"readAt,omitempty"`
+}
+
+type ListMessageMeta struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	// Unread counts the messages in the thread sent by the other participant and not read yet
+	Unread int `json:"unread"`
+}
+
+func makeMessageResponse(m MatchMessageList, userID int64) MessageResponse {
+	return MessageResponse{
+		ID:         m.UID,
+		Body:       m.Body,
+		SenderName: m.SenderName,
+		IsMine:     m.SenderUserID == userID,
+		CreatedAt:  m.CreatedAt,
+		ReadAt:     m.ReadAt,
+	}
+}
+
+// MessageEventData is pushed to the other participant when a message is sent.
+type MessageEventData struct {
+	MatchID string          `json:"matchId"`
+	Message MessageResponse `json:"message"`
+}
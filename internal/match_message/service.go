@@ -0,0 +1,132 @@
+package matchmessage
+
+import (
+	"context"
+	"fmt"
+
+	catmatch "github.com/citadel-corp/cats-social/internal/cat_match"
+	"github.com/citadel-corp/cats-social/internal/common/cursor"
+	"github.com/citadel-corp/cats-social/internal/common/id"
+	"github.com/citadel-corp/cats-social/internal/event"
+)
+
+const defaultListLimit = 50
+
+type Service interface {
+	Send(ctx context.Context, req SendMessagePayload, matchID string, userID int64) (*MessageResponse, error)
+	List(ctx context.Context, req ListMessagePayload, matchID string, userID int64) ([]MessageResponse, *ListMessageMeta, error)
+	MarkRead(ctx context.Context, matchID string, userID int64) error
+}
+
+type matchMessageService struct {
+	repository         Repository
+	catMatchRepository catmatch.Repository
+	publisher          event.Publisher
+}
+
+// NewService creates the message Service. New messages are published to their recipient through
+// publisher, a nil publisher discards them.
+func NewService(repository Repository, catMatchRepository catmatch.Repository, publisher event.Publisher) Service {
+	if publisher == nil {
+		publisher = event.Discard
+	}
+	return &matchMessageService{repository: repository, catMatchRepository: catMatchRepository, publisher: publisher}
+}
+
+// Send implements Service. Only the two participants can write, and only while the match is pending or approved.
+func (s *matchMessageService) Send(ctx context.Context, req SendMessagePayload, matchID string, userID int64) (*MessageResponse, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrValidationFailed, err)
+	}
+
+	match, err := s.catMatchRepository.GetByUIDAndUserID(ctx, matchID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !match.ApprovalStatus.IsOpen() {
+		return nil, ErrThreadClosed
+	}
+
+	message := &MatchMessage{
+		UID:          id.GenerateStringID(16),
+		MatchID:      match.ID,
+		SenderUserID: userID,
+		Body:         req.Body,
+	}
+	err = s.repository.Create(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := s.repository.GetListByUID(ctx, message.UID)
+	if err != nil {
+		return nil, err
+	}
+	recipientID := match.MatchUserId
+	if recipientID == userID {
+		recipientID = match.IssueUserId
+	}
+	s.publisher.Publish(ctx, recipientID, event.MessageReceived, MessageEventData{
+		MatchID: match.UID,
+		Message: makeMessageResponse(*m, recipientID),
+	})
+
+	res := makeMessageResponse(*m, userID)
+	return &res, nil
+}
+
+// List implements Service. Closed threads stay readable for both participants.
+func (s *matchMessageService) List(ctx context.Context, req ListMessagePayload, matchID string, userID int64) ([]MessageResponse, *ListMessageMeta, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrValidationFailed, err)
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultListLimit
+	}
+	if req.Cursor != "" {
+		req.After, err = cursor.Decode(req.Cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	match, err := s.catMatchRepository.GetByUIDAndUserID(ctx, matchID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	limit := req.Limit
+	req.Limit += 1
+	messages, err := s.repository.List(ctx, match.ID, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	unread, err := s.repository.CountUnread(ctx, match.ID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	meta := &ListMessageMeta{Limit: limit, Unread: unread}
+	if len(messages) > limit {
+		messages = messages[:limit]
+		last := messages[limit-1]
+		meta.NextCursor = cursor.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	res := make([]MessageResponse, len(messages))
+	for i, m := range messages {
+		res[i] = makeMessageResponse(m, userID)
+	}
+	return res, meta, nil
+}
+
+// MarkRead implements Service. It sets the read receipt on every message the user received in the thread.
+func (s *matchMessageService) MarkRead(ctx context.Context, matchID string, userID int64) error {
+	match, err := s.catMatchRepository.GetByUIDAndUserID(ctx, matchID, userID)
+	if err != nil {
+		return err
+	}
+	_, err = s.repository.MarkRead(ctx, match.ID, userID)
+	return err
+}
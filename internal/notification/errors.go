@@ -0,0 +1,13 @@
+package notification
+
+import (
+	"errors"
+
+	"github.com/citadel-corp/cats-social/internal/common/cursor"
+)
+
+var (
+	ErrNotificationNotFound = errors.New("notification not found")
+	ErrValidationFailed     = errors.New("validation failed")
+	ErrInvalidCursor        = cursor.ErrInvalidCursor
+)
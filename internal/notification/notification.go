@@ -0,0 +1,18 @@
+package notification
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/citadel-corp/cats-social/internal/event"
+)
+
+type Notification struct {
+	ID        int64
+	UID       string
+	UserID    int64
+	Type      event.Type
+	Data      json.RawMessage
+	CreatedAt time.Time
+	ReadAt    *time.Time
+}
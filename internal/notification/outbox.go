@@ -0,0 +1,39 @@
+package notification
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+
+	"github.com/citadel-corp/cats-social/internal/event"
+	"github.com/citadel-corp/cats-social/internal/outbox"
+)
+
+// NewOutboxRelay returns an outbox handler storing the user events that userEvents derives from each
+// domain event as notifications. Each is stored under a uid derived from the outbox event and its
+// recipient, so an event handed over again doesn't notify anyone twice.
+func NewOutboxRelay(service Service, userEvents func(outbox.Event) ([]event.Event, error)) outbox.Handler {
+	return func(ctx context.Context, e outbox.Event) error {
+		events, err := userEvents(e)
+		if err != nil {
+			// retrying can't fix an event that can't be read
+			slog.Error(fmt.Sprintf("notification relay, outbox event %d: %v", e.ID, err))
+			return nil
+		}
+		for _, ue := range events {
+			err = service.Record(ctx, outboxUID(e.ID, ue.UserID), ue.UserID, ue.Type, ue.Data)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// outboxUID derives the uid of the notification a user gets for an outbox event.
+func outboxUID(eventID int64, userID int64) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("outbox:%d:%d", eventID, userID)))
+	return hex.EncodeToString(sum[:])[:16]
+}
@@ -0,0 +1,163 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/citadel-corp/cats-social/internal/common/db"
+	"github.com/citadel-corp/cats-social/internal/event"
+)
+
+type Repository interface {
+	Create(ctx context.Context, notification *Notification) error
+	List(ctx context.Context, userID int64, req ListNotificationPayload) ([]Notification, error)
+	CountUnread(ctx context.Context, userID int64) (int, error)
+	MarkRead(ctx context.Context, uid string, userID int64) error
+	MarkAllRead(ctx context.Context, userID int64) error
+	ListPreferences(ctx context.Context, userID int64) (map[event.Type]bool, error)
+	SetPreferences(ctx context.Context, userID int64, preferences map[event.Type]bool) error
+}
+
+type dbRepository struct {
+	db *db.DB
+}
+
+func NewRepository(db *db.DB) Repository {
+	return &dbRepository{db: db}
+}
+
+// Create implements Repository. Nothing is stored when the user turned off notifications of its type or a
+// notification with the same uid exists.
+func (d *dbRepository) Create(ctx context.Context, notification *Notification) error {
+	createNotificationQuery := `
+		INSERT INTO notifications (uid, user_id, type, data)
+		SELECT $1, $2, $3, $4
+		WHERE NOT EXISTS (
+			SELECT 1 FROM notification_preferences np
+			WHERE np.user_id = $2 AND np.type = $3 AND NOT np.enabled
+		)
+		ON CONFLICT (uid) DO NOTHING;
+	`
+	_, err := d.db.DB().ExecContext(ctx, createNotificationQuery,
+		notification.UID, notification.UserID, notification.Type, []byte(notification.Data))
+	return err
+}
+
+// List implements Repository. Notifications are listed newest first, starting after req.After when set.
+func (d *dbRepository) List(ctx context.Context, userID int64, req ListNotificationPayload) ([]Notification, error) {
+	listQuery := `
+		SELECT id, uid, user_id, type, data, created_at, read_at
+		FROM notifications
+		WHERE user_id = $1 `
+	params := []interface{}{userID}
+	if req.Unread {
+		listQuery += "AND read_at IS NULL "
+	}
+	if req.After != nil {
+		listQuery += fmt.Sprintf("AND (created_at, id) < ($%d::TIMESTAMP, $%d) ", len(params)+1, len(params)+2)
+		params = append(params, req.After.Timestamp(), req.After.ID)
+	}
+	listQuery += fmt.Sprintf("ORDER BY created_at DESC, id DESC LIMIT %d;", req.Limit)
+	rows, err := d.db.DB().QueryContext(ctx, listQuery, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make([]Notification, 0)
+	for rows.Next() {
+		n := Notification{}
+		err = rows.Scan(&n.ID, &n.UID, &n.UserID, &n.Type, &n.Data, &n.CreatedAt, &n.ReadAt)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, n)
+	}
+	return res, rows.Err()
+}
+
+// CountUnread implements Repository.
+func (d *dbRepository) CountUnread(ctx context.Context, userID int64) (int, error) {
+	countUnreadQuery := `
+		SELECT COUNT(*)
+		FROM notifications
+		WHERE user_id = $1 AND read_at IS NULL;
+	`
+	var count int
+	err := d.db.DB().QueryRowContext(ctx, countUnreadQuery, userID).Scan(&count)
+	return count, err
+}
+
+// MarkRead implements Repository. Marking a notification read again keeps the first read time.
+func (d *dbRepository) MarkRead(ctx context.Context, uid string, userID int64) error {
+	markReadQuery := `
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, current_timestamp)
+		WHERE uid = $1 AND user_id = $2;
+	`
+	res, err := d.db.DB().ExecContext(ctx, markReadQuery, uid, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// MarkAllRead implements Repository.
+func (d *dbRepository) MarkAllRead(ctx context.Context, userID int64) error {
+	markAllReadQuery := `
+		UPDATE notifications
+		SET read_at = current_timestamp
+		WHERE user_id = $1 AND read_at IS NULL;
+	`
+	_, err := d.db.DB().ExecContext(ctx, markAllReadQuery, userID)
+	return err
+}
+
+// ListPreferences implements Repository. Only the types the user changed are returned.
+func (d *dbRepository) ListPreferences(ctx context.Context, userID int64) (map[event.Type]bool, error) {
+	listPreferencesQuery := `
+		SELECT type, enabled
+		FROM notification_preferences
+		WHERE user_id = $1;
+	`
+	rows, err := d.db.DB().QueryContext(ctx, listPreferencesQuery, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make(map[event.Type]bool)
+	for rows.Next() {
+		var typ event.Type
+		var enabled bool
+		if err = rows.Scan(&typ, &enabled); err != nil {
+			return nil, err
+		}
+		res[typ] = enabled
+	}
+	return res, rows.Err()
+}
+
+// SetPreferences implements Repository.
+func (d *dbRepository) SetPreferences(ctx context.Context, userID int64, preferences map[event.Type]bool) error {
+	return d.db.StartTx(ctx, func(tx *sql.Tx) error {
+		setPreferenceQuery := `
+			INSERT INTO notification_preferences (user_id, type, enabled)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (user_id, type) DO UPDATE SET enabled = EXCLUDED.enabled;
+		`
+		for typ, enabled := range preferences {
+			_, err := tx.ExecContext(ctx, setPreferenceQuery, userID, typ, enabled)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,45 @@
+package notification
+
+import (
+	"github.com/citadel-corp/cats-social/internal/common/cursor"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+type ListNotificationPayload struct {
+	Unread bool   `schema:"unread" binding:"omitempty"`
+	Cursor string `schema:"cursor" binding:"omitempty"`
+	Limit  int    `schema:"limit" binding:"omitempty"`
+
+	After *cursor.Cursor `schema:"-"`
+}
+
+func (p ListNotificationPayload) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Limit, validation.Min(0), validation.Max(100)),
+	)
+}
+
+type PreferencePayload struct {
+	Type    string `json:"type"`
+	Enabled bool   `json:"enabled"`
+}
+
+func (p PreferencePayload) Validate() error {
+	types := make([]interface{}, len(notificationTypes))
+	for i, t := range notificationTypes {
+		types[i] = string(t)
+	}
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Type, validation.Required, validation.In(types...)),
+	)
+}
+
+type UpdatePreferencesPayload struct {
+	Preferences []PreferencePayload `json:"preferences"`
+}
+
+func (p UpdatePreferencesPayload) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Preferences, validation.Required),
+	)
+}
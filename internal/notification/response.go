@@ -0,0 +1,37 @@
+package notification
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type NotificationResponse struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	IsRead    bool            `json:"isRead"`
+	CreatedAt time.Time       `json:"createdAt"`
+	ReadAt    *time.Time      `json:"readAt,omitempty"`
+}
+
+type ListNotificationMeta struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	Unread     int    `json:"unread"`
+}
+
+type PreferenceResponse struct {
+	Type    string `json:"type"`
+	Enabled bool   `json:"enabled"`
+}
+
+func makeNotificationResponse(n Notification) NotificationResponse {
+	return NotificationResponse{
+		ID:        n.UID,
+		Type:      string(n.Type),
+		Data:      n.Data,
+		IsRead:    n.ReadAt != nil,
+		CreatedAt: n.CreatedAt,
+		ReadAt:    n.ReadAt,
+	}
+}
@@ -0,0 +1,148 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/citadel-corp/cats-social/internal/common/cursor"
+	"github.com/citadel-corp/cats-social/internal/common/id"
+	"github.com/citadel-corp/cats-social/internal/event"
+)
+
+const defaultListLimit = 20
+
+// notificationTypes are the events kept as notifications, users don't need to be told about their own cat edits.
+var notificationTypes = []event.Type{
+	event.MatchReceived, event.MatchApproved, event.MatchRejected, event.MatchWithdrawn,
+	event.MatchExpiring, event.MatchExpired, event.MatchDissolved, event.MatchCancelled, event.MessageReceived,
+}
+
+// Service keeps a user's notifications. It is an event.Publisher, so every event published to a user
+// is stored for them unless they turned its type off.
+type Service interface {
+	event.Publisher
+	// Record stores a notification under uid, a notification already stored under uid is left alone
+	Record(ctx context.Context, uid string, userID int64, typ event.Type, data any) error
+	List(ctx context.Context, req ListNotificationPayload, userID int64) ([]NotificationResponse, *ListNotificationMeta, error)
+	MarkRead(ctx context.Context, id string, userID int64) error
+	MarkAllRead(ctx context.Context, userID int64) error
+	GetPreferences(ctx context.Context, userID int64) ([]PreferenceResponse, error)
+	UpdatePreferences(ctx context.Context, req UpdatePreferencesPayload, userID int64) ([]PreferenceResponse, error)
+}
+
+type notificationService struct {
+	repository Repository
+}
+
+func NewService(repository Repository) Service {
+	return &notificationService{repository: repository}
+}
+
+// Publish implements event.Publisher. Failures are logged, they never fail the change that caused the event.
+func (s *notificationService) Publish(ctx context.Context, userID int64, typ event.Type, data any) {
+	err := s.Record(ctx, id.GenerateStringID(16), userID, typ, data)
+	if err != nil {
+		slog.Error(fmt.Sprintf("notification %s for user %d: %v", typ, userID, err))
+	}
+}
+
+// Record implements Service.
+func (s *notificationService) Record(ctx context.Context, uid string, userID int64, typ event.Type, data any) error {
+	if !slices.Contains(notificationTypes, typ) {
+		return nil
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return s.repository.Create(ctx, &Notification{
+		UID:    uid,
+		UserID: userID,
+		Type:   typ,
+		Data:   raw,
+	})
+}
+
+// List implements Service. One extra notification is read to tell whether there is a next page.
+func (s *notificationService) List(ctx context.Context, req ListNotificationPayload, userID int64) ([]NotificationResponse, *ListNotificationMeta, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrValidationFailed, err)
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultListLimit
+	}
+	if req.Cursor != "" {
+		req.After, err = cursor.Decode(req.Cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	limit := req.Limit
+	req.Limit += 1
+	notifications, err := s.repository.List(ctx, userID, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	unread, err := s.repository.CountUnread(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	meta := &ListNotificationMeta{Limit: limit, Unread: unread}
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+		last := notifications[limit-1]
+		meta.NextCursor = cursor.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	res := make([]NotificationResponse, len(notifications))
+	for i, n := range notifications {
+		res[i] = makeNotificationResponse(n)
+	}
+	return res, meta, nil
+}
+
+// MarkRead implements Service.
+func (s *notificationService) MarkRead(ctx context.Context, id string, userID int64) error {
+	return s.repository.MarkRead(ctx, id, userID)
+}
+
+// MarkAllRead implements Service.
+func (s *notificationService) MarkAllRead(ctx context.Context, userID int64) error {
+	return s.repository.MarkAllRead(ctx, userID)
+}
+
+// GetPreferences implements Service. Every event type is listed, enabled unless the user turned it off.
+func (s *notificationService) GetPreferences(ctx context.Context, userID int64) ([]PreferenceResponse, error) {
+	preferences, err := s.repository.ListPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]PreferenceResponse, len(notificationTypes))
+	for i, typ := range notificationTypes {
+		enabled, ok := preferences[typ]
+		res[i] = PreferenceResponse{Type: string(typ), Enabled: enabled || !ok}
+	}
+	return res, nil
+}
+
+// UpdatePreferences implements Service. Types left out of the request keep their current setting.
+func (s *notificationService) UpdatePreferences(ctx context.Context, req UpdatePreferencesPayload, userID int64) ([]PreferenceResponse, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrValidationFailed, err)
+	}
+	preferences := make(map[event.Type]bool, len(req.Preferences))
+	for _, p := range req.Preferences {
+		preferences[event.Type(p.Type)] = p.Enabled
+	}
+	err = s.repository.SetPreferences(ctx, userID, preferences)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPreferences(ctx, userID)
+}
@@ -0,0 +1,143 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	batchSize = 100
+	// a failing event is retried with backoff and dead-lettered after maxAttempts
+	maxAttempts  = 10
+	firstBackoff = 10 * time.Second
+	maxBackoff   = time.Hour
+	// retention is how long processed events are kept before they are pruned
+	retention     = 24 * time.Hour
+	pruneInterval = time.Hour
+
+	defaultPollInterval = time.Second
+)
+
+// errHeldBack marks an event not handed to a subscriber because an earlier event of its aggregate failed.
+var errHeldBack = errors.New("held back behind a failed event of the same aggregate")
+
+// Handler handles a domain event. An error leaves the event unprocessed and it is handed over again after
+// a backoff, so handlers must cope with seeing an event more than once. An event failing maxAttempts times
+// is dead-lettered: it stays in the outbox, unprocessed, and its aggregate moves on without it.
+type Handler func(ctx context.Context, e Event) error
+
+type subscriber struct {
+	name    string
+	handler Handler
+	types   map[Type]bool
+}
+
+// Dispatcher hands the events in the outbox to in-process subscribers. Every subscriber gets each event
+// at least once, and the events of one aggregate in order: after a failure the remaining events of that
+// aggregate wait for the failed one to succeed or be dead-lettered.
+type Dispatcher struct {
+	repository  Repository
+	interval    time.Duration
+	subscribers []subscriber
+}
+
+// NewDispatcher creates a Dispatcher polling the outbox every interval.
+func NewDispatcher(repository Repository, interval time.Duration) *Dispatcher {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	return &Dispatcher{repository: repository, interval: interval}
+}
+
+// Subscribe registers handler for the given event types, or every event without types. The name tracks
+// what the subscriber has processed, so it must stay the same across restarts. Subscribe before Run.
+func (d *Dispatcher) Subscribe(name string, handler Handler, types ...Type) {
+	s := subscriber{name: name, handler: handler}
+	if len(types) > 0 {
+		s.types = make(map[Type]bool)
+		for _, typ := range types {
+			s.types[typ] = true
+		}
+	}
+	d.subscribers = append(d.subscribers, s)
+}
+
+// Run dispatches events until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	var prunedAt time.Time
+	for {
+		err := d.Dispatch(ctx)
+		if err != nil {
+			slog.Error(fmt.Sprintf("dispatch outbox: %v", err))
+		}
+		if time.Since(prunedAt) > pruneInterval {
+			d.prune(ctx)
+			prunedAt = time.Now()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch hands every pending event to the subscribers.
+func (d *Dispatcher) Dispatch(ctx context.Context) error {
+	for _, s := range d.subscribers {
+		err := d.dispatch(ctx, s)
+		if err != nil {
+			return fmt.Errorf("subscriber %s: %w", s.name, err)
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context, s subscriber) error {
+	// aggregates with a failed event in this pass
+	failed := make(map[string]bool)
+	handle := func(e Event) error {
+		aggregate := e.AggregateType + ":" + e.AggregateID
+		if failed[aggregate] {
+			return errHeldBack
+		}
+		if s.types != nil && !s.types[e.Type] {
+			return nil
+		}
+		err := s.handler(ctx, e)
+		if err != nil {
+			failed[aggregate] = true
+			slog.Error(fmt.Sprintf("outbox subscriber %s, event %d %s: %v", s.name, e.ID, e.Type, err))
+		}
+		return err
+	}
+	for {
+		handled, err := d.repository.Process(ctx, s.name, batchSize, handle)
+		if err != nil {
+			return err
+		}
+		if handled < batchSize {
+			return nil
+		}
+	}
+}
+
+func (d *Dispatcher) prune(ctx context.Context) {
+	names := make([]string, len(d.subscribers))
+	for i, s := range d.subscribers {
+		names[i] = s.name
+	}
+	pruned, err := d.repository.Prune(ctx, names, retention)
+	if err != nil {
+		slog.Error(fmt.Sprintf("prune outbox: %v", err))
+		return
+	}
+	if pruned > 0 {
+		slog.Info(fmt.Sprintf("pruned %d outbox events", pruned))
+	}
+}
@@ -0,0 +1,60 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/citadel-corp/cats-social/internal/common/db"
+)
+
+// Type names a domain event, something that changed in the system regardless of who gets told about it.
+type Type string
+
+const (
+	CatCreated     Type = "CatCreated"
+	CatUpdated     Type = "CatUpdated"
+	CatDeleted     Type = "CatDeleted"
+	CatTransferred Type = "CatTransferred"
+	MatchRequested Type = "MatchRequested"
+	MatchApproved  Type = "MatchApproved"
+	MatchRejected  Type = "MatchRejected"
+	MatchWithdrawn Type = "MatchWithdrawn"
+	MatchExpired   Type = "MatchExpired"
+	MatchCancelled Type = "MatchCancelled"
+	MatchDissolved Type = "MatchDissolved"
+)
+
+// Event is a domain event recorded in the outbox. Events of one aggregate are dispatched in the order
+// they were recorded.
+type Event struct {
+	ID            int64
+	AggregateType string
+	AggregateID   string
+	Type          Type
+	Payload       json.RawMessage
+	CreatedAt     time.Time
+}
+
+// Decode unmarshals the event payload into v.
+func (e Event) Decode(v any) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+// Record writes a domain event to the outbox. It must run in the transaction making the change, so the
+// event is stored if and only if the change is.
+func Record(ctx context.Context, q db.Querier, aggregateType string, aggregateID string, typ Type, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	recordQuery := `
+		INSERT INTO outbox_events (
+			aggregate_type, aggregate_id, type, payload
+		) VALUES (
+			$1, $2, $3, $4
+		);
+	`
+	_, err = q.ExecContext(ctx, recordQuery, aggregateType, aggregateID, typ, string(data))
+	return err
+}
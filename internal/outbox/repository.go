@@ -0,0 +1,163 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"time"
+
+	"github.com/citadel-corp/cats-social/internal/common/db"
+	"github.com/lib/pq"
+)
+
+type Repository interface {
+	Process(ctx context.Context, subscriber string, limit int, fn func(Event) error) (int, error)
+	Prune(ctx context.Context, subscribers []string, olderThan time.Duration) (int64, error)
+}
+
+type dbRepository struct {
+	db *db.DB
+}
+
+func NewRepository(db *db.DB) Repository {
+	return &dbRepository{db: db}
+}
+
+// Process implements Repository. It hands up to limit events the subscriber hasn't processed yet to fn,
+// oldest first, and returns how many it handed over. An event fn returns nil for is marked processed, a
+// failed one is retried after a backoff and dead-lettered after maxAttempts, so failing events never hold
+// up the rest of the outbox. Events waiting behind a failing event of the same aggregate are left out
+// until it succeeds or is dead-lettered.
+//
+// A session lock on the subscriber, held on a dedicated connection, keeps instances sharing the database
+// from processing the same subscriber at the same time. fn runs outside of any transaction.
+func (d *dbRepository) Process(ctx context.Context, subscriber string, limit int, fn func(Event) error) (int, error) {
+	conn, err := d.db.DB().Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	lockKey := "outbox:" + subscriber
+	var locked bool
+	err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext($1));`, lockKey).Scan(&locked)
+	if err != nil || !locked {
+		return 0, err
+	}
+	defer func() {
+		// unlocked even when ctx is done, a connection that can't be unlocked is discarded instead of going
+		// back to the pool still holding the lock
+		_, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock(hashtext($1));`, lockKey)
+		if err != nil {
+			conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}()
+
+	listQuery := `
+		SELECT o.id, o.aggregate_type, o.aggregate_id, o.type, o.payload, o.created_at
+		FROM outbox_events o
+		WHERE NOT EXISTS (
+			SELECT 1 FROM outbox_processed p WHERE p.subscriber = $1 AND p.event_id = o.id
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM outbox_attempts a
+			WHERE a.subscriber = $1 AND a.event_id = o.id
+			AND (a.dead_at IS NOT NULL OR a.next_attempt_at > current_timestamp)
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM outbox_attempts a
+			JOIN outbox_events f ON f.id = a.event_id
+			WHERE a.subscriber = $1 AND a.dead_at IS NULL AND f.id < o.id
+			AND f.aggregate_type = o.aggregate_type AND f.aggregate_id = o.aggregate_id
+		)
+		ORDER BY o.id
+		LIMIT $2;
+	`
+	rows, err := conn.QueryContext(ctx, listQuery, subscriber, limit)
+	if err != nil {
+		return 0, err
+	}
+	events := make([]Event, 0)
+	for rows.Next() {
+		e := Event{}
+		err = rows.Scan(&e.ID, &e.AggregateType, &e.AggregateID, &e.Type, &e.Payload, &e.CreatedAt)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		events = append(events, e)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, e := range events {
+		err = fn(e)
+		if errors.Is(err, errHeldBack) {
+			continue
+		}
+		if err != nil {
+			err = recordFailure(ctx, conn, subscriber, e.ID, err)
+		} else {
+			err = markProcessed(ctx, conn, subscriber, e.ID)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(events), nil
+}
+
+func markProcessed(ctx context.Context, conn *sql.Conn, subscriber string, eventID int64) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	markQuery := `
+		INSERT INTO outbox_processed (subscriber, event_id)
+		VALUES ($1, $2);
+	`
+	_, err = tx.ExecContext(ctx, markQuery, subscriber, eventID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM outbox_attempts WHERE subscriber = $1 AND event_id = $2;`, subscriber, eventID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// recordFailure counts a failed attempt and schedules the next one, doubling the wait every time.
+func recordFailure(ctx context.Context, conn *sql.Conn, subscriber string, eventID int64, failure error) error {
+	failureQuery := `
+		INSERT INTO outbox_attempts (subscriber, event_id, attempts, next_attempt_at, last_error)
+		VALUES ($1, $2, 1, current_timestamp + make_interval(secs => $3), $4)
+		ON CONFLICT (subscriber, event_id) DO UPDATE
+		SET attempts = outbox_attempts.attempts + 1,
+		next_attempt_at = current_timestamp + make_interval(secs => LEAST($3 * power(2, outbox_attempts.attempts), $5)),
+		last_error = EXCLUDED.last_error,
+		dead_at = CASE WHEN outbox_attempts.attempts + 1 >= $6 THEN current_timestamp END;
+	`
+	_, err := conn.ExecContext(ctx, failureQuery, subscriber, eventID,
+		firstBackoff.Seconds(), failure.Error(), maxBackoff.Seconds(), maxAttempts)
+	return err
+}
+
+// Prune implements Repository. Deletes events older than olderThan that every subscriber has processed.
+func (d *dbRepository) Prune(ctx context.Context, subscribers []string, olderThan time.Duration) (int64, error) {
+	pruneQuery := `
+		DELETE FROM outbox_events o
+		WHERE o.created_at < current_timestamp - make_interval(secs => $1)
+		AND (
+			SELECT COUNT(*) FROM outbox_processed p WHERE p.event_id = o.id AND p.subscriber = ANY($2)
+		) = cardinality($2::VARCHAR[]);
+	`
+	res, err := d.db.DB().ExecContext(ctx, pruneQuery, olderThan.Seconds(), pq.Array(subscribers))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
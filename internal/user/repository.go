@@ -6,6 +6,7 @@ import (
 	"errors"
 
 	"github.com/citadel-corp/cats-social/internal/common/db"
+	"github.com/citadel-corp/cats-social/internal/common/geo"
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
@@ -13,6 +14,8 @@ type Repository interface {
 	Create(ctx context.Context, user *User) (*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
 	GetByID(ctx context.Context, id uint64) (*User, error)
+	UpdateLocation(ctx context.Context, id int64, location geo.Location) error
+	SetEmailSubscribed(ctx context.Context, id int64, subscribed bool) error
 }
 
 type dbRepository struct {
@@ -73,17 +76,67 @@ func (d *dbRepository) GetByEmail(ctx context.Context, email string) (*User, err
 
 func (d *dbRepository) GetByID(ctx context.Context, id uint64) (*User, error) {
 	getUserQuery := `
-		SELECT id, uid, email, name, product_sold_total, hashed_password FROM users
-		WHERE uid = $1;
+		SELECT id, uid, email, name, hashed_password, city, created_at, email_unsubscribed_at FROM users
+		WHERE id = $1;
 	`
 	row := d.db.DB().QueryRowContext(ctx, getUserQuery, id)
 	u := &User{}
-	err := row.Scan(&u.ID, &u.UID, &u.Email, &u.Name, &u.HashedPassword)
+	var city sql.NullString
+	err := row.Scan(&u.ID, &u.UID, &u.Email, &u.Name, &u.HashedPassword, &city, &u.CreatedAt, &u.EmailUnsubscribedAt)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrUserNotFound
 	}
 	if err != nil {
 		return nil, err
 	}
+	u.City = city.String
 	return u, nil
 }
+
+// UpdateLocation implements Repository.
+func (d *dbRepository) UpdateLocation(ctx context.Context, id int64, location geo.Location) error {
+	updateLocationQuery := `
+		UPDATE users
+		SET latitude = $1,
+		longitude = $2,
+		city = $3
+		WHERE id = $4;
+	`
+	var city interface{}
+	if location.City != "" {
+		city = location.City
+	}
+	row, err := d.db.DB().ExecContext(ctx, updateLocationQuery, location.Lat, location.Lng, city, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := row.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// SetEmailSubscribed implements Repository. Unsubscribing again keeps the first unsubscribe time.
+func (d *dbRepository) SetEmailSubscribed(ctx context.Context, id int64, subscribed bool) error {
+	updateSubscriptionQuery := `
+		UPDATE users
+		SET email_unsubscribed_at = CASE WHEN $1 THEN NULL ELSE COALESCE(email_unsubscribed_at, current_timestamp) END
+		WHERE id = $2;
+	`
+	row, err := d.db.DB().ExecContext(ctx, updateSubscriptionQuery, subscribed, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := row.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package user
 
 import (
+	"github.com/citadel-corp/cats-social/internal/common/geo"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/go-ozzo/ozzo-validation/v4/is"
 )
@@ -30,3 +31,7 @@ func (p LoginPayload) Validate() error {
 		validation.Field(&p.Password, validation.Required, validation.Length(5, 15)),
 	)
 }
+
+type UpdateLocationPayload struct {
+	geo.LocationPayload
+}
@@ -13,14 +13,20 @@ import (
 type Service interface {
 	Create(ctx context.Context, req CreateUserPayload) (*UserResponse, error)
 	Login(ctx context.Context, req LoginPayload) (*UserResponse, error)
+	UpdateLocation(ctx context.Context, req UpdateLocationPayload, userID int64) error
 }
 
 type userService struct {
 	repository Repository
+	notifier   Notifier
 }
 
-func NewService(repository Repository) Service {
-	return &userService{repository: repository}
+// NewService creates the user Service. Registrations and logins are reported to notifier unless it is nil.
+func NewService(repository Repository, notifier Notifier) Service {
+	if notifier == nil {
+		notifier = nopNotifier{}
+	}
+	return &userService{repository: repository, notifier: notifier}
 }
 
 func (s *userService) Create(ctx context.Context, req CreateUserPayload) (*UserResponse, error) {
@@ -38,10 +44,12 @@ func (s *userService) Create(ctx context.Context, req CreateUserPayload) (*UserR
 		Name:           req.Name,
 		HashedPassword: hashedPassword,
 	}
-	user, err = s.repository.Create(ctx, user)
+	created, err := s.repository.Create(ctx, user)
 	if err != nil {
 		return nil, err
 	}
+	user.ID = created.ID
+	s.notifier.Registered(ctx, user)
 	// create access token with signed jwt
 	accessToken, err := jwt.Sign(time.Hour*8, fmt.Sprint(user.ID))
 	if err != nil {
@@ -70,6 +78,7 @@ func (s *userService) Login(ctx context.Context, req LoginPayload) (*UserRespons
 	if !match {
 		return nil, ErrWrongPassword
 	}
+	s.notifier.LoggedIn(ctx, user)
 	// create access token with signed jwt
 	accessToken, err := jwt.Sign(time.Hour*8, fmt.Sprint(user.ID))
 	if err != nil {
@@ -81,3 +90,11 @@ func (s *userService) Login(ctx context.Context, req LoginPayload) (*UserRespons
 		AccessToken: accessToken,
 	}, nil
 }
+
+func (s *userService) UpdateLocation(ctx context.Context, req UpdateLocationPayload, userID int64) error {
+	err := req.Validate()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrValidationFailed, err)
+	}
+	return s.repository.UpdateLocation(ctx, userID, req.Location())
+}
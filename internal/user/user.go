@@ -1,9 +1,30 @@
 package user
 
+import (
+	"context"
+	"time"
+)
+
 type User struct {
 	ID             int64
 	UID            string
 	Email          string
 	Name           string
 	HashedPassword string
+	City           string
+	CreatedAt      time.Time
+	// EmailUnsubscribedAt is set once the user opted out of match emails
+	EmailUnsubscribedAt *time.Time
+}
+
+// Notifier is told about account events, e.g. to send the account emails.
+type Notifier interface {
+	Registered(ctx context.Context, user *User)
+	LoggedIn(ctx context.Context, user *User)
 }
+
+type nopNotifier struct{}
+
+func (nopNotifier) Registered(ctx context.Context, user *User) {}
+
+func (nopNotifier) LoggedIn(ctx context.Context, user *User) {}
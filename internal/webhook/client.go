@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// sendTimeout bounds a single delivery, whatever client the dispatcher was given.
+const sendTimeout = 10 * time.Second
+
+var ErrAddressNotAllowed = errors.New("webhook address is not allowed")
+
+// sharedAddressSpace is the carrier-grade NAT range, internal like RFC 1918 but not covered by netip.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// NewClient returns the http.Client deliveries are sent with. It only connects to public addresses,
+// checked on the resolved address of every connection, so hostnames resolving or redirecting to an
+// internal address are refused too.
+func NewClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: sendTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip, err := netip.ParseAddr(host)
+			if err != nil {
+				return err
+			}
+			if !isPublic(ip) {
+				return fmt.Errorf("%w: %s", ErrAddressNotAllowed, ip)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: sendTimeout,
+		Transport: &http.Transport{
+			// no proxy, it would be dialed instead of the webhook and skip the address check
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: sendTimeout,
+			MaxIdleConnsPerHost: 2,
+		},
+	}
+}
+
+// isPublic reports whether ip is a routable address outside the server's own networks.
+func isPublic(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsValid() && ip.IsGlobalUnicast() && !ip.IsPrivate() && !sharedAddressSpace.Contains(ip)
+}
+
+// validateURL accepts absolute http and https urls. Literal addresses are checked here already,
+// hostnames are checked by NewClient when they are dialed.
+func validateURL(value interface{}) error {
+	raw, _ := value.(string)
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return errors.New("must be a valid URL")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("must be an http or https URL")
+	}
+	if u.Hostname() == "" || u.User != nil {
+		return errors.New("must be a valid URL")
+	}
+	if ip, err := netip.ParseAddr(u.Hostname()); err == nil && !isPublic(ip) {
+		return errors.New("must not point to a private address")
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	claimBatch = 20
+	// claimLease covers sending a whole batch, so deliveries are never claimed again while in flight
+	claimLease   = claimBatch*sendTimeout + time.Minute
+	maxAttempts  = 10
+	firstBackoff = 30 * time.Second
+	maxBackoff   = 6 * time.Hour
+
+	defaultPollInterval = 5 * time.Second
+)
+
+// Dispatcher sends queued deliveries. Failed sends are retried with exponential backoff until
+// maxAttempts, after which the delivery is marked failed and only a manual redeliver sends it again.
+type Dispatcher struct {
+	repository Repository
+	client     *http.Client
+	interval   time.Duration
+}
+
+// NewDispatcher creates a Dispatcher polling the queue every interval. A nil client sends with NewClient,
+// which refuses internal addresses.
+func NewDispatcher(repository Repository, client *http.Client, interval time.Duration) *Dispatcher {
+	if client == nil {
+		client = NewClient()
+	}
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	return &Dispatcher{repository: repository, client: client, interval: interval}
+}
+
+// Run dispatches due deliveries until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		err := d.Dispatch(ctx)
+		if err != nil {
+			slog.Error(fmt.Sprintf("dispatch webhooks: %v", err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch sends every delivery due now, one batch at a time.
+func (d *Dispatcher) Dispatch(ctx context.Context) error {
+	for {
+		deliveries, err := d.repository.ClaimDue(ctx, claimBatch, claimLease)
+		if err != nil {
+			return err
+		}
+		for _, delivery := range deliveries {
+			d.send(ctx, &delivery)
+			err = d.repository.RecordAttempt(ctx, &delivery)
+			if err != nil {
+				return err
+			}
+		}
+		if len(deliveries) < claimBatch {
+			return nil
+		}
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery *Delivery) {
+	delivery.Attempts += 1
+	delivery.LastStatusCode = nil
+	delivery.LastError = nil
+
+	statusCode, err := d.post(ctx, delivery)
+	if statusCode != 0 {
+		delivery.LastStatusCode = &statusCode
+	}
+	if err == nil && statusCode/100 != 2 {
+		err = fmt.Errorf("unexpected status %d", statusCode)
+	}
+	if err == nil {
+		now := time.Now()
+		delivery.Status = Succeeded
+		delivery.DeliveredAt = &now
+		return
+	}
+
+	msg := err.Error()
+	delivery.LastError = &msg
+	if delivery.Attempts >= maxAttempts {
+		delivery.Status = Failed
+		return
+	}
+	delivery.NextAttemptAt = time.Now().Add(backoff(delivery.Attempts))
+}
+
+func (d *Dispatcher) post(ctx context.Context, delivery *Delivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "cats-social-webhooks")
+	req.Header.Set("X-Webhook-Event", string(delivery.EventType))
+	req.Header.Set("X-Webhook-Delivery", delivery.EventID)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// drained so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// backoff is how long to wait after the given number of failed attempts.
+func backoff(attempts int) time.Duration {
+	wait := firstBackoff
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return wait
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" with the webhook secret. Receivers compute
+// the same over the X-Webhook-Timestamp header and the raw body and compare it to X-Webhook-Signature.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
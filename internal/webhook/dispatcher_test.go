@@ -0,0 +1,170 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memRepository keeps the delivery queue in memory, the methods the dispatcher doesn't use panic.
+type memRepository struct {
+	Repository
+
+	mu       sync.Mutex
+	due      []Delivery
+	recorded []Delivery
+}
+
+func (m *memRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := min(limit, len(m.due))
+	claimed := m.due[:n]
+	m.due = m.due[n:]
+	return claimed, nil
+}
+
+func (m *memRepository) RecordAttempt(ctx context.Context, delivery *Delivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.recorded = append(m.recorded, *delivery)
+	return nil
+}
+
+func newDelivery(url string) Delivery {
+	return Delivery{
+		ID:        1,
+		UID:       "delivery",
+		EventID:   "0000000000000001",
+		EventType: "match.approved",
+		Payload:   []byte(`{"id":"0000000000000001"}`),
+		Status:    Pending,
+		URL:       url,
+		Secret:    "whsec_test",
+	}
+}
+
+func TestDispatchSignsAndDelivers(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	repo := &memRepository{due: []Delivery{newDelivery(receiver.URL)}}
+	err := NewDispatcher(repo, receiver.Client(), time.Second).Dispatch(context.Background())
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+
+	if len(repo.recorded) != 1 {
+		t.Fatalf("recorded %d attempts, want 1", len(repo.recorded))
+	}
+	delivery := repo.recorded[0]
+	if delivery.Status != Succeeded || delivery.DeliveredAt == nil || delivery.Attempts != 1 {
+		t.Errorf("delivery = %s after %d attempts, want succeeded after 1", delivery.Status, delivery.Attempts)
+	}
+	if delivery.LastStatusCode == nil || *delivery.LastStatusCode != http.StatusNoContent {
+		t.Errorf("last status code = %v, want 204", delivery.LastStatusCode)
+	}
+	timestamp := got.Header.Get("X-Webhook-Timestamp")
+	want := "sha256=" + Sign("whsec_test", timestamp, body)
+	if signature := got.Header.Get("X-Webhook-Signature"); signature != want {
+		t.Errorf("signature = %q, want %q", signature, want)
+	}
+	if event := got.Header.Get("X-Webhook-Event"); event != "match.approved" {
+		t.Errorf("event header = %q, want match.approved", event)
+	}
+}
+
+func TestDispatchRetriesWithBackoff(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	delivery := newDelivery(receiver.URL)
+	delivery.Attempts = 2
+	repo := &memRepository{due: []Delivery{delivery}}
+	before := time.Now()
+	err := NewDispatcher(repo, receiver.Client(), time.Second).Dispatch(context.Background())
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+
+	delivery = repo.recorded[0]
+	if delivery.Status != Pending || delivery.Attempts != 3 || delivery.LastError == nil {
+		t.Fatalf("delivery = %s after %d attempts, want pending with an error after 3", delivery.Status, delivery.Attempts)
+	}
+	if wait := delivery.NextAttemptAt.Sub(before); wait < 4*firstBackoff || wait > 4*firstBackoff+time.Minute {
+		t.Errorf("next attempt in %s, want about %s", wait, 4*firstBackoff)
+	}
+
+	delivery.Attempts = maxAttempts - 1
+	repo = &memRepository{due: []Delivery{delivery}}
+	err = NewDispatcher(repo, receiver.Client(), time.Second).Dispatch(context.Background())
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if status := repo.recorded[0].Status; status != Failed {
+		t.Errorf("delivery = %s after its last attempt, want failed", status)
+	}
+}
+
+func TestClientRefusesInternalAddresses(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("internal receiver was reached")
+	}))
+	defer receiver.Close()
+
+	repo := &memRepository{due: []Delivery{newDelivery(receiver.URL)}}
+	err := NewDispatcher(repo, nil, time.Second).Dispatch(context.Background())
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	delivery := repo.recorded[0]
+	if delivery.Status != Pending || delivery.LastError == nil || delivery.LastStatusCode != nil {
+		t.Fatalf("delivery to %s = %s, want a pending retry with a dial error", receiver.URL, delivery.Status)
+	}
+
+	_, err = NewClient().Get(receiver.URL)
+	if !errors.Is(err, ErrAddressNotAllowed) {
+		t.Errorf("Get %s: %v, want ErrAddressNotAllowed", receiver.URL, err)
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		url   string
+		valid bool
+	}{
+		{"https://example.com/hooks", true},
+		{"http://93.184.216.34:8080/hooks", true},
+		{"ftp://example.com/hooks", false},
+		{"file:///etc/passwd", false},
+		{"http://127.0.0.1/hooks", false},
+		{"http://10.0.0.8/hooks", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://[::1]/hooks", false},
+		{"http://[::ffff:192.168.1.1]/hooks", false},
+		{"http://100.64.0.1/hooks", false},
+	}
+	for _, tt := range tests {
+		err := validateURL(tt.url)
+		if (err == nil) != tt.valid {
+			t.Errorf("validateURL(%q) = %v, want valid %v", tt.url, err, tt.valid)
+		}
+	}
+	if isPublic(netip.MustParseAddr("0.0.0.0")) {
+		t.Error("0.0.0.0 is public")
+	}
+}
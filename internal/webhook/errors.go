@@ -0,0 +1,15 @@
+package webhook
+
+import (
+	"errors"
+
+	"github.com/citadel-corp/cats-social/internal/common/cursor"
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrWebhookInactive  = errors.New("webhook is not active")
+	ErrValidationFailed = errors.New("validation failed")
+	ErrInvalidCursor    = cursor.ErrInvalidCursor
+)
@@ -0,0 +1,32 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/citadel-corp/cats-social/internal/event"
+	"github.com/citadel-corp/cats-social/internal/outbox"
+)
+
+// NewOutboxRelay returns an outbox handler queueing deliveries of the user events that userEvents
+// derives from each domain event. Deliveries are queued under an event ID derived from the outbox event,
+// so an event handed over again reaches receivers with the same ID.
+func NewOutboxRelay(service Service, userEvents func(outbox.Event) ([]event.Event, error)) outbox.Handler {
+	return func(ctx context.Context, e outbox.Event) error {
+		events, err := userEvents(e)
+		if err != nil {
+			// retrying can't fix an event that can't be read
+			slog.Error(fmt.Sprintf("webhook relay, outbox event %d: %v", e.ID, err))
+			return nil
+		}
+		eventID := fmt.Sprintf("%016x", e.ID)
+		for _, ue := range events {
+			err = service.Enqueue(ctx, eventID, ue.UserID, ue.Type, ue.Data)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,296 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/citadel-corp/cats-social/internal/common/db"
+	"github.com/citadel-corp/cats-social/internal/event"
+	"github.com/lib/pq"
+)
+
+type Repository interface {
+	Create(ctx context.Context, webhook *Webhook) error
+	GetByUIDAndUserID(ctx context.Context, uid string, userID int64) (*Webhook, error)
+	ListByUserID(ctx context.Context, userID int64) ([]Webhook, error)
+	ListSubscribed(ctx context.Context, userID int64, typ event.Type) ([]Webhook, error)
+	Update(ctx context.Context, webhook *Webhook) error
+	Delete(ctx context.Context, uid string, userID int64) error
+	CreateDelivery(ctx context.Context, delivery *Delivery) error
+	GetDelivery(ctx context.Context, uid string, webhookID int64) (*Delivery, error)
+	ListDeliveries(ctx context.Context, webhookID int64, req ListDeliveryPayload) ([]Delivery, error)
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]Delivery, error)
+	RecordAttempt(ctx context.Context, delivery *Delivery) error
+}
+
+type dbRepository struct {
+	db *db.DB
+}
+
+func NewRepository(db *db.DB) Repository {
+	return &dbRepository{db: db}
+}
+
+const (
+	webhookColumns  = "id, uid, user_id, url, secret, event_types, is_active, created_at"
+	deliveryColumns = "d.id, d.uid, d.webhook_id, d.event_id, d.event_type, d.payload, d.status, d.attempts, d.next_attempt_at, d.last_status_code, d.last_error, d.created_at, d.delivered_at"
+)
+
+// Create implements Repository.
+func (d *dbRepository) Create(ctx context.Context, webhook *Webhook) error {
+	createWebhookQuery := `
+		INSERT INTO webhooks (
+			uid, user_id, url, secret, event_types
+		) VALUES (
+			$1, $2, $3, $4, $5
+		) RETURNING id, is_active, created_at;
+	`
+	row := d.db.DB().QueryRowContext(ctx, createWebhookQuery,
+		webhook.UID, webhook.UserID, webhook.URL, webhook.Secret, pq.Array(webhook.EventTypes))
+	return row.Scan(&webhook.ID, &webhook.IsActive, &webhook.CreatedAt)
+}
+
+// GetByUIDAndUserID implements Repository.
+func (d *dbRepository) GetByUIDAndUserID(ctx context.Context, uid string, userID int64) (*Webhook, error) {
+	getWebhookQuery := `
+		SELECT ` + webhookColumns + `
+		FROM webhooks
+		WHERE uid = $1 AND user_id = $2;
+	`
+	rows, err := d.db.DB().QueryContext(ctx, getWebhookQuery, uid, userID)
+	if err != nil {
+		return nil, err
+	}
+	res, err := scanWebhooks(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, ErrWebhookNotFound
+	}
+	return &res[0], nil
+}
+
+// ListByUserID implements Repository.
+func (d *dbRepository) ListByUserID(ctx context.Context, userID int64) ([]Webhook, error) {
+	listWebhookQuery := `
+		SELECT ` + webhookColumns + `
+		FROM webhooks
+		WHERE user_id = $1
+		ORDER BY created_at;
+	`
+	rows, err := d.db.DB().QueryContext(ctx, listWebhookQuery, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanWebhooks(rows)
+}
+
+// ListSubscribed implements Repository. Only active webhooks subscribed to typ are returned.
+func (d *dbRepository) ListSubscribed(ctx context.Context, userID int64, typ event.Type) ([]Webhook, error) {
+	listWebhookQuery := `
+		SELECT ` + webhookColumns + `
+		FROM webhooks
+		WHERE user_id = $1 AND is_active AND $2 = ANY(event_types);
+	`
+	rows, err := d.db.DB().QueryContext(ctx, listWebhookQuery, userID, typ)
+	if err != nil {
+		return nil, err
+	}
+	return scanWebhooks(rows)
+}
+
+// Update implements Repository.
+func (d *dbRepository) Update(ctx context.Context, webhook *Webhook) error {
+	updateWebhookQuery := `
+		UPDATE webhooks
+		SET url = $1, event_types = $2, is_active = $3
+		WHERE id = $4;
+	`
+	res, err := d.db.DB().ExecContext(ctx, updateWebhookQuery, webhook.URL, pq.Array(webhook.EventTypes), webhook.IsActive, webhook.ID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// Delete implements Repository. Its deliveries are deleted with it.
+func (d *dbRepository) Delete(ctx context.Context, uid string, userID int64) error {
+	deleteWebhookQuery := `
+		DELETE FROM webhooks
+		WHERE uid = $1 AND user_id = $2;
+	`
+	res, err := d.db.DB().ExecContext(ctx, deleteWebhookQuery, uid, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// CreateDelivery implements Repository. The delivery is due right away.
+func (d *dbRepository) CreateDelivery(ctx context.Context, delivery *Delivery) error {
+	createDeliveryQuery := `
+		INSERT INTO webhook_deliveries (
+			uid, webhook_id, event_id, event_type, payload
+		) VALUES (
+			$1, $2, $3, $4, $5
+		) RETURNING id, status, attempts, next_attempt_at, created_at;
+	`
+	row := d.db.DB().QueryRowContext(ctx, createDeliveryQuery,
+		delivery.UID, delivery.WebhookID, delivery.EventID, delivery.EventType, []byte(delivery.Payload))
+	return row.Scan(&delivery.ID, &delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.CreatedAt)
+}
+
+// GetDelivery implements Repository.
+func (d *dbRepository) GetDelivery(ctx context.Context, uid string, webhookID int64) (*Delivery, error) {
+	getDeliveryQuery := `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries d
+		WHERE d.uid = $1 AND d.webhook_id = $2;
+	`
+	rows, err := d.db.DB().QueryContext(ctx, getDeliveryQuery, uid, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	res, err := scanDeliveries(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, ErrDeliveryNotFound
+	}
+	return &res[0], nil
+}
+
+// ListDeliveries implements Repository. Deliveries are listed newest first, starting after req.After when set.
+func (d *dbRepository) ListDeliveries(ctx context.Context, webhookID int64, req ListDeliveryPayload) ([]Delivery, error) {
+	listQuery := `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries d
+		WHERE d.webhook_id = $1 `
+	params := []interface{}{webhookID}
+	if req.Status != "" {
+		listQuery += fmt.Sprintf("AND d.status = $%d ", len(params)+1)
+		params = append(params, req.Status)
+	}
+	if req.After != nil {
+		listQuery += fmt.Sprintf("AND (d.created_at, d.id) < ($%d::TIMESTAMP, $%d) ", len(params)+1, len(params)+2)
+		params = append(params, req.After.Timestamp(), req.After.ID)
+	}
+	listQuery += fmt.Sprintf("ORDER BY d.created_at DESC, d.id DESC LIMIT %d;", req.Limit)
+	rows, err := d.db.DB().QueryContext(ctx, listQuery, params...)
+	if err != nil {
+		return nil, err
+	}
+	return scanDeliveries(rows)
+}
+
+// ClaimDue implements Repository. Due deliveries of active webhooks are pushed back by lease so no other
+// dispatcher picks them up while they are being sent, a dispatcher dying mid-send only delays them.
+func (d *dbRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]Delivery, error) {
+	var res []Delivery
+	err := d.db.StartTx(ctx, func(tx *sql.Tx) error {
+		claimQuery := `
+			SELECT ` + deliveryColumns + `, w.url, w.secret
+			FROM webhook_deliveries d
+			JOIN webhooks w ON d.webhook_id = w.id
+			WHERE d.status = $1 AND d.next_attempt_at <= current_timestamp AND w.is_active
+			ORDER BY d.next_attempt_at
+			LIMIT $2
+			FOR UPDATE OF d SKIP LOCKED;
+		`
+		rows, err := tx.QueryContext(ctx, claimQuery, Pending, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		ids := make([]int64, 0)
+		for rows.Next() {
+			delivery := Delivery{}
+			err = rows.Scan(&delivery.ID, &delivery.UID, &delivery.WebhookID, &delivery.EventID, &delivery.EventType, &delivery.Payload,
+				&delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastStatusCode, &delivery.LastError,
+				&delivery.CreatedAt, &delivery.DeliveredAt, &delivery.URL, &delivery.Secret)
+			if err != nil {
+				return err
+			}
+			res = append(res, delivery)
+			ids = append(ids, delivery.ID)
+		}
+		if err = rows.Err(); err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		leaseQuery := `
+			UPDATE webhook_deliveries
+			SET next_attempt_at = current_timestamp + make_interval(secs => $1)
+			WHERE id = ANY($2);
+		`
+		_, err = tx.ExecContext(ctx, leaseQuery, lease.Seconds(), pq.Array(ids))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// RecordAttempt implements Repository.
+func (d *dbRepository) RecordAttempt(ctx context.Context, delivery *Delivery) error {
+	recordQuery := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, next_attempt_at = $3, last_status_code = $4, last_error = $5, delivered_at = $6
+		WHERE id = $7;
+	`
+	_, err := d.db.DB().ExecContext(ctx, recordQuery, delivery.Status, delivery.Attempts, delivery.NextAttemptAt,
+		delivery.LastStatusCode, delivery.LastError, delivery.DeliveredAt, delivery.ID)
+	return err
+}
+
+func scanWebhooks(rows *sql.Rows) ([]Webhook, error) {
+	defer rows.Close()
+	res := make([]Webhook, 0)
+	for rows.Next() {
+		w := Webhook{}
+		err := rows.Scan(&w.ID, &w.UID, &w.UserID, &w.URL, &w.Secret, pq.Array(&w.EventTypes), &w.IsActive, &w.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, w)
+	}
+	return res, rows.Err()
+}
+
+func scanDeliveries(rows *sql.Rows) ([]Delivery, error) {
+	defer rows.Close()
+	res := make([]Delivery, 0)
+	for rows.Next() {
+		delivery := Delivery{}
+		err := rows.Scan(&delivery.ID, &delivery.UID, &delivery.WebhookID, &delivery.EventID, &delivery.EventType, &delivery.Payload,
+			&delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastStatusCode, &delivery.LastError,
+			&delivery.CreatedAt, &delivery.DeliveredAt)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, delivery)
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"github.com/citadel-corp/cats-social/internal/common/cursor"
+	"github.com/citadel-corp/cats-social/internal/event"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+)
+
+var eventTypeRule = func() validation.Rule {
+	types := make([]interface{}, len(event.Types))
+	for i, t := range event.Types {
+		types[i] = string(t)
+	}
+	return validation.In(types...)
+}()
+
+type CreateWebhookPayload struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"eventTypes"`
+}
+
+func (p CreateWebhookPayload) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.URL, validation.Required, validation.Length(1, 2048), is.URL, validation.By(validateURL)),
+		validation.Field(&p.EventTypes, validation.Required, validation.Each(eventTypeRule)),
+	)
+}
+
+type UpdateWebhookPayload struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"eventTypes"`
+	// IsActive pauses or resumes deliveries, left as is when missing
+	IsActive *bool `json:"isActive"`
+}
+
+func (p UpdateWebhookPayload) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.URL, validation.Required, validation.Length(1, 2048), is.URL, validation.By(validateURL)),
+		validation.Field(&p.EventTypes, validation.Required, validation.Each(eventTypeRule)),
+	)
+}
+
+type ListDeliveryPayload struct {
+	Status string `schema:"status" binding:"omitempty"`
+	Cursor string `schema:"cursor" binding:"omitempty"`
+	Limit  int    `schema:"limit" binding:"omitempty"`
+
+	After *cursor.Cursor `schema:"-"`
+}
+
+func (p ListDeliveryPayload) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Status, validation.In(string(Pending), string(Succeeded), string(Failed))),
+		validation.Field(&p.Limit, validation.Min(0), validation.Max(100)),
+	)
+}
@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type WebhookResponse struct {
+	ID         string   `json:"id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"eventTypes"`
+	IsActive   bool     `json:"isActive"`
+	// Secret signs the payloads, it is only returned when the webhook is created
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type DeliveryResponse struct {
+	ID             string          `json:"id"`
+	EventID        string          `json:"eventId"`
+	EventType      string          `json:"eventType"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"nextAttemptAt,omitempty"`
+	LastStatusCode *int            `json:"lastStatusCode,omitempty"`
+	LastError      *string         `json:"lastError,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	DeliveredAt    *time.Time      `json:"deliveredAt,omitempty"`
+}
+
+type ListDeliveryMeta struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+func makeWebhookResponse(w Webhook) WebhookResponse {
+	return WebhookResponse{
+		ID:         w.UID,
+		URL:        w.URL,
+		EventTypes: w.EventTypes,
+		IsActive:   w.IsActive,
+		CreatedAt:  w.CreatedAt,
+	}
+}
+
+func makeDeliveryResponse(d Delivery) DeliveryResponse {
+	res := DeliveryResponse{
+		ID:             d.UID,
+		EventID:        d.EventID,
+		EventType:      string(d.EventType),
+		Payload:        d.Payload,
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+		DeliveredAt:    d.DeliveredAt,
+	}
+	if d.Status == Pending {
+		res.NextAttemptAt = &d.NextAttemptAt
+	}
+	return res
+}
@@ -0,0 +1,204 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/citadel-corp/cats-social/internal/common/cursor"
+	"github.com/citadel-corp/cats-social/internal/common/id"
+	"github.com/citadel-corp/cats-social/internal/event"
+)
+
+const defaultListLimit = 20
+
+// Service manages a user's webhooks. It is an event.Publisher queueing a delivery for every webhook
+// subscribed to the published event.
+type Service interface {
+	event.Publisher
+	// Enqueue queues a delivery of the event to every webhook of userID subscribed to typ
+	Enqueue(ctx context.Context, eventID string, userID int64, typ event.Type, data any) error
+	Create(ctx context.Context, req CreateWebhookPayload, userID int64) (*WebhookResponse, error)
+	List(ctx context.Context, userID int64) ([]WebhookResponse, error)
+	Update(ctx context.Context, req UpdateWebhookPayload, uid string, userID int64) (*WebhookResponse, error)
+	Delete(ctx context.Context, uid string, userID int64) error
+	ListDeliveries(ctx context.Context, req ListDeliveryPayload, uid string, userID int64) ([]DeliveryResponse, *ListDeliveryMeta, error)
+	Redeliver(ctx context.Context, uid string, deliveryID string, userID int64) (*DeliveryResponse, error)
+}
+
+type webhookService struct {
+	repository Repository
+}
+
+func NewService(repository Repository) Service {
+	return &webhookService{repository: repository}
+}
+
+// Publish implements event.Publisher. Failures are logged, they never fail the change that caused the event.
+func (s *webhookService) Publish(ctx context.Context, userID int64, typ event.Type, data any) {
+	err := s.Enqueue(ctx, id.GenerateStringID(16), userID, typ, data)
+	if err != nil {
+		slog.Error(fmt.Sprintf("webhooks for %s of user %d: %v", typ, userID, err))
+	}
+}
+
+// Enqueue implements Service.
+func (s *webhookService) Enqueue(ctx context.Context, eventID string, userID int64, typ event.Type, data any) error {
+	webhooks, err := s.repository.ListSubscribed(ctx, userID, typ)
+	if err != nil {
+		return err
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+	payload, err := json.Marshal(Payload{ID: eventID, Type: typ, CreatedAt: time.Now(), Data: data})
+	if err != nil {
+		return err
+	}
+	for _, w := range webhooks {
+		err = s.repository.CreateDelivery(ctx, &Delivery{
+			UID:       id.GenerateStringID(16),
+			WebhookID: w.ID,
+			EventID:   eventID,
+			EventType: typ,
+			Payload:   payload,
+		})
+		if err != nil {
+			return fmt.Errorf("webhook %s: %w", w.UID, err)
+		}
+	}
+	return nil
+}
+
+// Create implements Service. The signing secret is only returned here.
+func (s *webhookService) Create(ctx context.Context, req CreateWebhookPayload, userID int64) (*WebhookResponse, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrValidationFailed, err)
+	}
+	webhook := &Webhook{
+		UID:        id.GenerateStringID(16),
+		UserID:     userID,
+		URL:        req.URL,
+		Secret:     "whsec_" + id.GenerateStringID(40),
+		EventTypes: req.EventTypes,
+	}
+	err = s.repository.Create(ctx, webhook)
+	if err != nil {
+		return nil, err
+	}
+	res := makeWebhookResponse(*webhook)
+	res.Secret = webhook.Secret
+	return &res, nil
+}
+
+// List implements Service.
+func (s *webhookService) List(ctx context.Context, userID int64) ([]WebhookResponse, error) {
+	webhooks, err := s.repository.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]WebhookResponse, len(webhooks))
+	for i, w := range webhooks {
+		res[i] = makeWebhookResponse(w)
+	}
+	return res, nil
+}
+
+// Update implements Service.
+func (s *webhookService) Update(ctx context.Context, req UpdateWebhookPayload, uid string, userID int64) (*WebhookResponse, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrValidationFailed, err)
+	}
+	webhook, err := s.repository.GetByUIDAndUserID(ctx, uid, userID)
+	if err != nil {
+		return nil, err
+	}
+	webhook.URL = req.URL
+	webhook.EventTypes = req.EventTypes
+	if req.IsActive != nil {
+		webhook.IsActive = *req.IsActive
+	}
+	err = s.repository.Update(ctx, webhook)
+	if err != nil {
+		return nil, err
+	}
+	res := makeWebhookResponse(*webhook)
+	return &res, nil
+}
+
+// Delete implements Service.
+func (s *webhookService) Delete(ctx context.Context, uid string, userID int64) error {
+	return s.repository.Delete(ctx, uid, userID)
+}
+
+// ListDeliveries implements Service. One extra delivery is read to tell whether there is a next page.
+func (s *webhookService) ListDeliveries(ctx context.Context, req ListDeliveryPayload, uid string, userID int64) ([]DeliveryResponse, *ListDeliveryMeta, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrValidationFailed, err)
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultListLimit
+	}
+	if req.Cursor != "" {
+		req.After, err = cursor.Decode(req.Cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	webhook, err := s.repository.GetByUIDAndUserID(ctx, uid, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	limit := req.Limit
+	req.Limit += 1
+	deliveries, err := s.repository.ListDeliveries(ctx, webhook.ID, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	meta := &ListDeliveryMeta{Limit: limit}
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+		last := deliveries[limit-1]
+		meta.NextCursor = cursor.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	res := make([]DeliveryResponse, len(deliveries))
+	for i, d := range deliveries {
+		res[i] = makeDeliveryResponse(d)
+	}
+	return res, meta, nil
+}
+
+// Redeliver implements Service. The event is queued again as a new delivery with the same event ID, the
+// original delivery stays in the log as it was.
+func (s *webhookService) Redeliver(ctx context.Context, uid string, deliveryID string, userID int64) (*DeliveryResponse, error) {
+	webhook, err := s.repository.GetByUIDAndUserID(ctx, uid, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !webhook.IsActive {
+		return nil, ErrWebhookInactive
+	}
+	original, err := s.repository.GetDelivery(ctx, deliveryID, webhook.ID)
+	if err != nil {
+		return nil, err
+	}
+	delivery := &Delivery{
+		UID:       id.GenerateStringID(16),
+		WebhookID: webhook.ID,
+		EventID:   original.EventID,
+		EventType: original.EventType,
+		Payload:   original.Payload,
+	}
+	err = s.repository.CreateDelivery(ctx, delivery)
+	if err != nil {
+		return nil, err
+	}
+	res := makeDeliveryResponse(*delivery)
+	return &res, nil
+}
@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/citadel-corp/cats-social/internal/event"
+)
+
+type DeliveryStatus string
+
+const (
+	Pending   DeliveryStatus = "pending"
+	Succeeded DeliveryStatus = "succeeded"
+	Failed    DeliveryStatus = "failed"
+)
+
+type Webhook struct {
+	ID         int64
+	UID        string
+	UserID     int64
+	URL        string
+	Secret     string
+	EventTypes []string
+	IsActive   bool
+	CreatedAt  time.Time
+}
+
+// Delivery is one event queued for one webhook, and the log of trying to send it.
+type Delivery struct {
+	ID             int64
+	UID            string
+	WebhookID      int64
+	EventID        string
+	EventType      event.Type
+	Payload        json.RawMessage
+	Status         DeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode *int
+	LastError      *string
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+
+	// where to send a claimed delivery
+	URL    string
+	Secret string
+}
+
+// Payload is the JSON body posted to webhooks. Redeliveries keep the event ID, so receivers can
+// drop events they already handled.
+type Payload struct {
+	ID        string     `json:"id"`
+	Type      event.Type `json:"type"`
+	CreatedAt time.Time  `json:"createdAt"`
+	Data      any        `json:"data"`
+}
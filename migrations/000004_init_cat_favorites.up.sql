@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS
+cat_favorites (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    cat_id INT NOT NULL,
+    created_at TIMESTAMP DEFAULT current_timestamp,
+    UNIQUE (user_id, cat_id)
+);
+
+ALTER TABLE cat_favorites
+	ADD CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE cat_favorites
+	ADD CONSTRAINT fk_cat_id FOREIGN KEY (cat_id) REFERENCES cats(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS cat_favorites_cat_id
+	ON cat_favorites(cat_id);
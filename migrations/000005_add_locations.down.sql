@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS cats_location;
+
+ALTER TABLE cats
+	DROP COLUMN IF EXISTS latitude,
+	DROP COLUMN IF EXISTS longitude,
+	DROP COLUMN IF EXISTS city;
+
+ALTER TABLE users
+	DROP COLUMN IF EXISTS latitude,
+	DROP COLUMN IF EXISTS longitude,
+	DROP COLUMN IF EXISTS city;
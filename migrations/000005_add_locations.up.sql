@@ -0,0 +1,12 @@
+ALTER TABLE users
+	ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION,
+	ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION,
+	ADD COLUMN IF NOT EXISTS city VARCHAR;
+
+ALTER TABLE cats
+	ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION,
+	ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION,
+	ADD COLUMN IF NOT EXISTS city VARCHAR;
+
+CREATE INDEX IF NOT EXISTS cats_location
+	ON cats(latitude, longitude);
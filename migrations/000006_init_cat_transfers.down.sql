@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS cat_transfers;
+DROP TYPE IF EXISTS cat_transfers_status;
+-- 'cancelled' stays on cat_matches_approval, postgres can't drop enum values
@@ -0,0 +1,32 @@
+-- pending matches of a transferred cat are cancelled
+ALTER TYPE cat_matches_approval ADD VALUE IF NOT EXISTS 'cancelled';
+
+DROP TYPE IF EXISTS cat_transfers_status;
+CREATE TYPE cat_transfers_status AS ENUM('pending', 'accepted', 'declined', 'cancelled');
+
+CREATE TABLE IF NOT EXISTS
+cat_transfers(
+    id SERIAL PRIMARY KEY,
+    uid CHAR(16) UNIQUE NOT NULL,
+    cat_id INT NOT NULL,
+    from_user_id INT NOT NULL,
+    to_user_id INT NOT NULL,
+    status cat_transfers_status NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP DEFAULT current_timestamp,
+    responded_at TIMESTAMP
+);
+
+ALTER TABLE cat_transfers
+	ADD CONSTRAINT fk_cat_id FOREIGN KEY (cat_id) REFERENCES cats(id) ON DELETE CASCADE;
+ALTER TABLE cat_transfers
+	ADD CONSTRAINT fk_from_user_id FOREIGN KEY (from_user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE cat_transfers
+	ADD CONSTRAINT fk_to_user_id FOREIGN KEY (to_user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS cat_transfers_uid
+	ON cat_transfers USING HASH (uid);
+CREATE INDEX IF NOT EXISTS cat_transfers_cat_id
+	ON cat_transfers(cat_id);
+-- a cat can only have one transfer in flight
+CREATE UNIQUE INDEX IF NOT EXISTS cat_transfers_pending_cat_id
+	ON cat_transfers(cat_id) WHERE status = 'pending';
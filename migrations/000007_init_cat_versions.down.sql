@@ -0,0 +1,9 @@
+ALTER TABLE cat_matches
+	DROP COLUMN IF EXISTS issuer_cat_version,
+	DROP COLUMN IF EXISTS matched_cat_version;
+
+DROP TABLE IF EXISTS cat_versions;
+DROP TYPE IF EXISTS cat_versions_action;
+
+ALTER TABLE cats
+	DROP COLUMN IF EXISTS version;
@@ -0,0 +1,41 @@
+ALTER TABLE cats
+	ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+
+DROP TYPE IF EXISTS cat_versions_action;
+CREATE TYPE cat_versions_action AS ENUM('create', 'update', 'delete');
+
+-- cat_uid has no foreign key so history outlives deleted cats
+CREATE TABLE IF NOT EXISTS
+cat_versions(
+    id SERIAL PRIMARY KEY,
+    cat_uid CHAR(16) NOT NULL,
+    version INT NOT NULL,
+    action cat_versions_action NOT NULL,
+    actor_user_id INT,
+    snapshot JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT current_timestamp,
+    UNIQUE (cat_uid, version)
+);
+
+ALTER TABLE cat_versions
+	ADD CONSTRAINT fk_actor_user_id FOREIGN KEY (actor_user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+-- existing cats start their history at version 1
+INSERT INTO cat_versions (cat_uid, version, action, actor_user_id, snapshot, created_at)
+SELECT uid, 1, 'create', user_id,
+	jsonb_build_object(
+		'name', name,
+		'race', race,
+		'sex', sex,
+		'ageInMonth', age_in_month,
+		'description', description,
+		'imageUrls', COALESCE(to_jsonb(image_urls), '[]'::jsonb),
+		'city', COALESCE(city, '')
+	),
+	created_at
+FROM cats
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE cat_matches
+	ADD COLUMN IF NOT EXISTS issuer_cat_version INT NOT NULL DEFAULT 1,
+	ADD COLUMN IF NOT EXISTS matched_cat_version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS cats_visibility_idx;
+
+ALTER TABLE cats
+	DROP COLUMN IF EXISTS visibility;
+
+DROP TYPE IF EXISTS cats_visibility;
@@ -0,0 +1,8 @@
+DROP TYPE IF EXISTS cats_visibility;
+CREATE TYPE cats_visibility AS ENUM('public', 'unlisted', 'private');
+
+ALTER TABLE cats
+	ADD COLUMN IF NOT EXISTS visibility cats_visibility NOT NULL DEFAULT 'public';
+
+CREATE INDEX IF NOT EXISTS cats_visibility_idx
+	ON cats(visibility);
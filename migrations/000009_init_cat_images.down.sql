@@ -0,0 +1,7 @@
+ALTER TABLE cats
+	ADD COLUMN IF NOT EXISTS image_urls TEXT[];
+
+UPDATE cats c
+SET image_urls = ARRAY(SELECT ci.url FROM cat_images ci WHERE ci.cat_id = c.id ORDER BY ci.position);
+
+DROP TABLE IF EXISTS cat_images;
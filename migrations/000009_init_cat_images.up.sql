@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS
+cat_images(
+    id SERIAL PRIMARY KEY,
+    uid CHAR(16) UNIQUE NOT NULL,
+    cat_id INT NOT NULL,
+    url TEXT NOT NULL,
+    position INT NOT NULL,
+    caption VARCHAR(200) NOT NULL DEFAULT '',
+    is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT current_timestamp
+);
+
+ALTER TABLE cat_images
+	ADD CONSTRAINT fk_cat_id FOREIGN KEY (cat_id) REFERENCES cats(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS cat_images_uid
+	ON cat_images USING HASH (uid);
+CREATE INDEX IF NOT EXISTS cat_images_cat_id_position
+	ON cat_images(cat_id, position);
+-- at most one cover photo per cat
+CREATE UNIQUE INDEX IF NOT EXISTS cat_images_primary
+	ON cat_images(cat_id) WHERE is_primary;
+
+-- move every url of the old array into the gallery, the first one becomes the cover
+INSERT INTO cat_images (uid, cat_id, url, position, is_primary, created_at)
+SELECT substr(md5(random()::text || c.id || '-' || i.position), 1, 16), c.id, i.url, i.position - 1, i.position = 1, c.created_at
+FROM cats c, unnest(c.image_urls) WITH ORDINALITY AS i(url, position);
+
+ALTER TABLE cats
+	DROP COLUMN IF EXISTS image_urls;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS cat_tags;
+DROP TABLE IF EXISTS tags;
@@ -0,0 +1,45 @@
+CREATE TABLE IF NOT EXISTS
+tags (
+    id SERIAL PRIMARY KEY,
+    slug VARCHAR(30) UNIQUE NOT NULL,
+    name VARCHAR(30) NOT NULL,
+    is_curated BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by_user_id INT,
+    created_at TIMESTAMP DEFAULT current_timestamp
+);
+
+ALTER TABLE tags
+	ADD CONSTRAINT fk_created_by_user_id FOREIGN KEY (created_by_user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE TABLE IF NOT EXISTS
+cat_tags (
+    cat_id INT NOT NULL,
+    tag_id INT NOT NULL,
+    created_at TIMESTAMP DEFAULT current_timestamp,
+    PRIMARY KEY (cat_id, tag_id)
+);
+
+ALTER TABLE cat_tags
+	ADD CONSTRAINT fk_cat_id FOREIGN KEY (cat_id) REFERENCES cats(id) ON DELETE CASCADE;
+ALTER TABLE cat_tags
+	ADD CONSTRAINT fk_tag_id FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS cat_tags_tag_id
+	ON cat_tags(tag_id);
+
+INSERT INTO tags (slug, name, is_curated) VALUES
+	('indoor', 'Indoor', TRUE),
+	('outdoor', 'Outdoor', TRUE),
+	('calm', 'Calm', TRUE),
+	('playful', 'Playful', TRUE),
+	('affectionate', 'Affectionate', TRUE),
+	('shy', 'Shy', TRUE),
+	('vocal', 'Vocal', TRUE),
+	('good-with-kids', 'Good with kids', TRUE),
+	('good-with-cats', 'Good with cats', TRUE),
+	('good-with-dogs', 'Good with dogs', TRUE),
+	('vaccinated', 'Vaccinated', TRUE),
+	('neutered', 'Neutered', TRUE),
+	('litter-trained', 'Litter trained', TRUE),
+	('special-needs', 'Special needs', TRUE)
+ON CONFLICT (slug) DO NOTHING;
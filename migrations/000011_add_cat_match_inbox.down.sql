@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS cat_matches_unread;
+DROP INDEX IF EXISTS cat_matches_issuer_user_id_created_at;
+DROP INDEX IF EXISTS cat_matches_matched_user_id_created_at;
+
+ALTER TABLE cat_matches
+	DROP COLUMN IF EXISTS receiver_viewed_at;
@@ -0,0 +1,10 @@
+ALTER TABLE cat_matches
+	ADD COLUMN IF NOT EXISTS receiver_viewed_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS cat_matches_matched_user_id_created_at
+	ON cat_matches(matched_user_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS cat_matches_issuer_user_id_created_at
+	ON cat_matches(issuer_user_id, created_at DESC, id DESC);
+-- unread incoming requests are counted on every inbox load
+CREATE INDEX IF NOT EXISTS cat_matches_unread
+	ON cat_matches(matched_user_id) WHERE receiver_viewed_at IS NULL;
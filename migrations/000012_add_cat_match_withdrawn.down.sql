@@ -0,0 +1,3 @@
+-- 'withdrawn' stays on cat_matches_approval, postgres can't drop enum values
+ALTER TABLE cat_matches
+	DROP COLUMN IF EXISTS responded_at;
@@ -0,0 +1,5 @@
+-- issuers withdraw their pending requests instead of deleting them
+ALTER TYPE cat_matches_approval ADD VALUE IF NOT EXISTS 'withdrawn';
+
+ALTER TABLE cat_matches
+	ADD COLUMN IF NOT EXISTS responded_at TIMESTAMP;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS cat_matches_pending_pair;
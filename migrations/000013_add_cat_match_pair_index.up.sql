@@ -0,0 +1,15 @@
+-- keep the oldest of duplicate pending requests between the same two cats, in either direction
+UPDATE cat_matches cm
+SET approval_status = 'cancelled', responded_at = current_timestamp
+WHERE cm.approval_status = 'pending' AND EXISTS (
+	SELECT 1 FROM cat_matches o
+	WHERE o.approval_status = 'pending'
+	AND LEAST(o.issuer_cat_id, o.matched_cat_id) = LEAST(cm.issuer_cat_id, cm.matched_cat_id)
+	AND GREATEST(o.issuer_cat_id, o.matched_cat_id) = GREATEST(cm.issuer_cat_id, cm.matched_cat_id)
+	AND (o.created_at, o.id) < (cm.created_at, cm.id)
+);
+
+-- two cats can only have one pending request between them, whoever sent it
+CREATE UNIQUE INDEX IF NOT EXISTS cat_matches_pending_pair
+	ON cat_matches(LEAST(issuer_cat_id, matched_cat_id), GREATEST(issuer_cat_id, matched_cat_id))
+	WHERE approval_status = 'pending';
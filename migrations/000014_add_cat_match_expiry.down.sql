@@ -0,0 +1,5 @@
+-- 'expired' stays on cat_matches_approval, postgres can't drop enum values
+DROP INDEX IF EXISTS cat_matches_pending_created_at;
+
+ALTER TABLE cat_matches
+	DROP COLUMN IF EXISTS reminded_at;
@@ -0,0 +1,8 @@
+-- pending matches older than the configured ttl are expired by the service
+ALTER TYPE cat_matches_approval ADD VALUE IF NOT EXISTS 'expired';
+
+ALTER TABLE cat_matches
+	ADD COLUMN IF NOT EXISTS reminded_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS cat_matches_pending_created_at
+	ON cat_matches(created_at) WHERE approval_status = 'pending';
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS
+match_messages(
+    id SERIAL PRIMARY KEY,
+    uid CHAR(16) UNIQUE NOT NULL,
+    match_id INT NOT NULL,
+    sender_user_id INT NOT NULL,
+    body VARCHAR(1000) NOT NULL,
+    created_at TIMESTAMP DEFAULT current_timestamp,
+    read_at TIMESTAMP
+);
+
+ALTER TABLE match_messages
+	ADD CONSTRAINT fk_match_id FOREIGN KEY (match_id) REFERENCES cat_matches(id) ON DELETE CASCADE;
+ALTER TABLE match_messages
+	ADD CONSTRAINT fk_sender_user_id FOREIGN KEY (sender_user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+-- threads are paged newest first
+CREATE INDEX IF NOT EXISTS match_messages_match_id_created_at
+	ON match_messages(match_id, created_at DESC, id DESC);
+-- unread counts only look at messages nobody has read yet
+CREATE INDEX IF NOT EXISTS match_messages_unread
+	ON match_messages(match_id, sender_user_id) WHERE read_at IS NULL;
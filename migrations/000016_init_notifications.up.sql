@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS
+notifications(
+    id SERIAL PRIMARY KEY,
+    uid CHAR(16) UNIQUE NOT NULL,
+    user_id INT NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    data JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT current_timestamp,
+    read_at TIMESTAMP
+);
+
+ALTER TABLE notifications
+	ADD CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS notifications_user_id_created_at
+	ON notifications(user_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS notifications_unread
+	ON notifications(user_id) WHERE read_at IS NULL;
+
+-- only types a user changed are stored, every other type is enabled
+CREATE TABLE IF NOT EXISTS
+notification_preferences(
+    user_id INT NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    PRIMARY KEY (user_id, type)
+);
+
+ALTER TABLE notification_preferences
+	ADD CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
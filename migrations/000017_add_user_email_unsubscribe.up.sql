@@ -0,0 +1,2 @@
+-- set when the user unsubscribed from match emails, account emails are always sent
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_unsubscribed_at TIMESTAMP;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TYPE IF EXISTS webhook_deliveries_status;
+DROP TABLE IF EXISTS webhooks;
@@ -0,0 +1,46 @@
+CREATE TABLE IF NOT EXISTS
+webhooks(
+    id SERIAL PRIMARY KEY,
+    uid CHAR(16) UNIQUE NOT NULL,
+    user_id INT NOT NULL,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(64) NOT NULL,
+    event_types VARCHAR(32)[] NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP DEFAULT current_timestamp
+);
+
+ALTER TABLE webhooks
+	ADD CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS webhooks_user_id
+	ON webhooks(user_id);
+
+DROP TYPE IF EXISTS webhook_deliveries_status;
+CREATE TYPE webhook_deliveries_status AS ENUM('pending', 'succeeded', 'failed');
+
+-- the delivery queue and its log, a delivery stays pending until it succeeds or runs out of attempts
+CREATE TABLE IF NOT EXISTS
+webhook_deliveries(
+    id SERIAL PRIMARY KEY,
+    uid CHAR(16) UNIQUE NOT NULL,
+    webhook_id INT NOT NULL,
+    event_id CHAR(16) NOT NULL,
+    event_type VARCHAR(32) NOT NULL,
+    payload JSONB NOT NULL,
+    status webhook_deliveries_status NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    last_status_code INT,
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT current_timestamp,
+    delivered_at TIMESTAMP
+);
+
+ALTER TABLE webhook_deliveries
+	ADD CONSTRAINT fk_webhook_id FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due
+	ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_id_created_at
+	ON webhook_deliveries(webhook_id, created_at DESC, id DESC);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS outbox_processed;
+DROP TABLE IF EXISTS outbox_events;
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS
+outbox_events(
+    id BIGSERIAL PRIMARY KEY,
+    aggregate_type VARCHAR(32) NOT NULL,
+    aggregate_id VARCHAR(32) NOT NULL,
+    type VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS outbox_events_created_at
+	ON outbox_events(created_at);
+
+-- one row per event a subscriber has handled, removed along with the event when it is pruned
+CREATE TABLE IF NOT EXISTS
+outbox_processed(
+    subscriber VARCHAR(64) NOT NULL,
+    event_id BIGINT NOT NULL,
+    processed_at TIMESTAMP DEFAULT current_timestamp,
+    PRIMARY KEY (subscriber, event_id)
+);
+
+ALTER TABLE outbox_processed
+	ADD CONSTRAINT fk_event_id FOREIGN KEY (event_id) REFERENCES outbox_events(id) ON DELETE CASCADE;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS cat_decisions;
+DROP TYPE IF EXISTS cat_decisions_decision;
@@ -0,0 +1,22 @@
+DROP TYPE IF EXISTS cat_decisions_decision;
+CREATE TYPE cat_decisions_decision AS ENUM('pass', 'like');
+
+-- what the owner of cat_id decided about candidate_cat_id while discovering, one row per pair so a
+-- candidate is never shown twice
+CREATE TABLE IF NOT EXISTS
+cat_decisions(
+    id SERIAL PRIMARY KEY,
+    cat_id INT NOT NULL,
+    candidate_cat_id INT NOT NULL,
+    user_id INT NOT NULL,
+    decision cat_decisions_decision NOT NULL,
+    created_at TIMESTAMP DEFAULT current_timestamp,
+    UNIQUE (cat_id, candidate_cat_id)
+);
+
+ALTER TABLE cat_decisions
+	ADD CONSTRAINT fk_cat_id FOREIGN KEY (cat_id) REFERENCES cats(id) ON DELETE CASCADE;
+ALTER TABLE cat_decisions
+	ADD CONSTRAINT fk_candidate_cat_id FOREIGN KEY (candidate_cat_id) REFERENCES cats(id) ON DELETE CASCADE;
+ALTER TABLE cat_decisions
+	ADD CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
@@ -0,0 +1,7 @@
+-- 'dissolved' stays on cat_matches_approval, postgres can't drop enum values
+DROP INDEX IF EXISTS cat_matches_dissolved_pair;
+
+ALTER TABLE cat_matches
+	DROP COLUMN IF EXISTS dissolve_reason,
+	DROP COLUMN IF EXISTS dissolved_by_user_id,
+	DROP COLUMN IF EXISTS dissolved_at;
@@ -0,0 +1,12 @@
+-- owners can end an approved match, the row is kept as history
+ALTER TYPE cat_matches_approval ADD VALUE IF NOT EXISTS 'dissolved';
+
+ALTER TABLE cat_matches
+	ADD COLUMN IF NOT EXISTS dissolved_at TIMESTAMP,
+	ADD COLUMN IF NOT EXISTS dissolved_by_user_id INT REFERENCES users(id) ON DELETE SET NULL,
+	ADD COLUMN IF NOT EXISTS dissolve_reason VARCHAR(200);
+
+-- the cooldown looks up the last dissolved match between two cats
+CREATE INDEX IF NOT EXISTS cat_matches_dissolved_pair
+	ON cat_matches(LEAST(issuer_cat_id, matched_cat_id), GREATEST(issuer_cat_id, matched_cat_id), dissolved_at DESC)
+	WHERE dissolved_at IS NOT NULL;
@@ -0,0 +1,8 @@
+ALTER TABLE cats
+	ADD COLUMN IF NOT EXISTS has_matched BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE cats c
+SET has_matched = true
+WHERE EXISTS (SELECT 1 FROM cat_pairings p WHERE p.cat_id = c.id AND p.ended_at IS NULL);
+
+DROP TABLE IF EXISTS cat_pairings;
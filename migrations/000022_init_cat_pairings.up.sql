@@ -0,0 +1,53 @@
+-- a cat is paired with another one from the approval of their match until it ends, one row per cat so
+-- a cat's current pairing is a single lookup. a cat is matched while it has a pairing that hasn't ended.
+CREATE TABLE IF NOT EXISTS
+cat_pairings(
+    id SERIAL PRIMARY KEY,
+    match_id INT NOT NULL,
+    cat_id INT NOT NULL,
+    partner_cat_id INT NOT NULL,
+    started_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    ended_at TIMESTAMP,
+    UNIQUE (match_id, cat_id)
+);
+
+ALTER TABLE cat_pairings
+	ADD CONSTRAINT fk_match_id FOREIGN KEY (match_id) REFERENCES cat_matches(id) ON DELETE CASCADE;
+ALTER TABLE cat_pairings
+	ADD CONSTRAINT fk_cat_id FOREIGN KEY (cat_id) REFERENCES cats(id) ON DELETE CASCADE;
+ALTER TABLE cat_pairings
+	ADD CONSTRAINT fk_partner_cat_id FOREIGN KEY (partner_cat_id) REFERENCES cats(id) ON DELETE CASCADE;
+
+-- a cat can be paired many times over its life but only once at a time
+CREATE UNIQUE INDEX IF NOT EXISTS cat_pairings_active_cat
+	ON cat_pairings(cat_id) WHERE ended_at IS NULL;
+
+-- approved and dissolved matches become pairings, cats flagged as matched without an approved match
+-- are free again. old data can have a cat in several approved matches: only a match that is the newest
+-- of both its cats becomes an active pairing, so a pair gets both of its rows or neither.
+WITH approved AS (
+	SELECT cm.id, cm.issuer_cat_id, cm.matched_cat_id, COALESCE(cm.responded_at, cm.created_at, current_timestamp) AS started_at
+	FROM cat_matches cm
+	WHERE cm.approval_status = 'approved'
+	AND EXISTS (SELECT 1 FROM cats c WHERE c.id = cm.issuer_cat_id)
+	AND EXISTS (SELECT 1 FROM cats c WHERE c.id = cm.matched_cat_id)
+),
+ranked AS (
+	SELECT a.id, ROW_NUMBER() OVER (PARTITION BY side.cat_id ORDER BY a.started_at DESC, a.id DESC) AS newest
+	FROM approved a
+	CROSS JOIN LATERAL (VALUES (a.issuer_cat_id), (a.matched_cat_id)) AS side(cat_id)
+),
+kept AS (
+	SELECT id FROM ranked GROUP BY id HAVING MAX(newest) = 1
+)
+INSERT INTO cat_pairings (match_id, cat_id, partner_cat_id, started_at, ended_at)
+SELECT cm.id, pair.cat_id, pair.partner_cat_id, COALESCE(cm.responded_at, cm.created_at, current_timestamp),
+CASE WHEN cm.approval_status = 'dissolved' THEN COALESCE(cm.dissolved_at, current_timestamp) END
+FROM cat_matches cm
+CROSS JOIN LATERAL (VALUES (cm.issuer_cat_id, cm.matched_cat_id), (cm.matched_cat_id, cm.issuer_cat_id)) AS pair(cat_id, partner_cat_id)
+WHERE (cm.approval_status = 'dissolved' OR cm.id IN (SELECT id FROM kept))
+AND EXISTS (SELECT 1 FROM cats c WHERE c.id = cm.issuer_cat_id)
+AND EXISTS (SELECT 1 FROM cats c WHERE c.id = cm.matched_cat_id);
+
+ALTER TABLE cats
+	DROP COLUMN IF EXISTS has_matched;
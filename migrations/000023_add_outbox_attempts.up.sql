@@ -0,0 +1,15 @@
+-- failed deliveries of an event to a subscriber. the event is retried with backoff and dead-lettered
+-- once it runs out of attempts, dead-lettered events are kept until someone looks at them.
+CREATE TABLE IF NOT EXISTS
+outbox_attempts(
+    subscriber VARCHAR(64) NOT NULL,
+    event_id BIGINT NOT NULL,
+    attempts INT NOT NULL DEFAULT 1,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_error TEXT NOT NULL,
+    dead_at TIMESTAMP,
+    PRIMARY KEY (subscriber, event_id)
+);
+
+ALTER TABLE outbox_attempts
+	ADD CONSTRAINT fk_event_id FOREIGN KEY (event_id) REFERENCES outbox_events(id) ON DELETE CASCADE;
@@ -0,0 +1,2 @@
+DELETE FROM outbox_processed
+WHERE subscriber IN ('events.cat', 'events.match', 'notifications.match', 'emails.match');
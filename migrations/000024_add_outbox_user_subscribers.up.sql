@@ -0,0 +1,7 @@
+-- notifications, live events and emails moved onto the outbox. events recorded before that were already
+-- published directly, so the new subscribers start after them instead of telling everyone again.
+INSERT INTO outbox_processed (subscriber, event_id)
+SELECT s.subscriber, o.id
+FROM outbox_events o
+CROSS JOIN (VALUES ('events.cat'), ('events.match'), ('notifications.match'), ('emails.match')) AS s(subscriber)
+ON CONFLICT DO NOTHING;